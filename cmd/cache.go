@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/gkwa/wholeoverride/wholeoverride"
+)
+
+var cacheBaseDir string
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the parse cache",
+	Long:  `Manage the cache of parsed markdown files that generate keeps in the base directory.`,
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove the parse cache",
+	Long:  `Remove the cache of parsed markdown files so that the next run parses every file again.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		logger := LoggerFrom(cmd.Context())
+		logger.Info("Running cache clear command")
+
+		return wholeoverride.ClearCache(wholeoverride.Options{BaseDir: cacheBaseDir, Logger: logger})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheClearCmd)
+	cacheClearCmd.Flags().
+		StringVar(&cacheBaseDir, "basedir", "", "Base directory containing markdown files")
+	if err := cacheClearCmd.MarkFlagRequired("basedir"); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/gkwa/wholeoverride/wholeoverride"
+)
+
+const vaultConfigName = ".wholeoverride.yaml"
+
+// loadVaultConfig returns the global configuration merged with the
+// .wholeoverride.yaml file in baseDir, whose settings take precedence.
+func loadVaultConfig(logger logr.Logger, baseDir string) (*viper.Viper, error) {
+	cfg := viper.New()
+	if err := cfg.MergeConfigMap(viper.AllSettings()); err != nil {
+		return nil, fmt.Errorf("error merging global config: %w", err)
+	}
+
+	path := filepath.Join(baseDir, vaultConfigName)
+	if _, err := os.Stat(path); err != nil {
+		logger.V(2).Info("No vault config file", "path", path)
+		return cfg, nil
+	}
+
+	cfg.SetConfigFile(path)
+	if err := cfg.MergeInConfig(); err != nil {
+		return nil, fmt.Errorf("error reading vault config %s: %w", path, err)
+	}
+	logger.V(1).Info("Using vault config file", "path", path)
+
+	return cfg, nil
+}
+
+// creatorsDir returns the --creators-dir flag of cmd when given, else the
+// creators_dir setting.
+func creatorsDir(cmd *cobra.Command, cfg *viper.Viper, flag string) string {
+	return stringSetting(cmd, cfg, "creators-dir", "creators_dir", flag)
+}
+
+// stringSetting returns value, the value of the flag called name, when it
+// was given on the command line, else the config setting key.
+func stringSetting(cmd *cobra.Command, cfg *viper.Viper, name, key, value string) string {
+	if cmd.Flags().Changed(name) {
+		return value
+	}
+	return cfg.GetString(key)
+}
+
+// stringSliceSetting is stringSetting for flags that take a list.
+func stringSliceSetting(cmd *cobra.Command, cfg *viper.Viper, name, key string, value []string) []string {
+	if cmd.Flags().Changed(name) {
+		return value
+	}
+	return cfg.GetStringSlice(key)
+}
+
+// detection returns the recipe and creator rules from the --recipe-rule
+// and --creator-rule flags of cmd when given, else from the recipe_rule and
+// creator_rule settings.
+func detection(cmd *cobra.Command, cfg *viper.Viper, recipeFlag, creatorFlag string) (wholeoverride.Detection, error) {
+	var d wholeoverride.Detection
+	var err error
+	if d.Recipe, err = detectionRule(cmd, cfg, "recipe-rule", "recipe_rule", recipeFlag); err != nil {
+		return wholeoverride.Detection{}, fmt.Errorf("invalid recipe rule: %w", err)
+	}
+	if d.Creator, err = detectionRule(cmd, cfg, "creator-rule", "creator_rule", creatorFlag); err != nil {
+		return wholeoverride.Detection{}, fmt.Errorf("invalid creator rule: %w", err)
+	}
+	return d, nil
+}
+
+// detectionRule reads a rule from the flag called name or else the setting
+// key, which is either written in the compact form of the flag or as a map
+// of conditions.
+func detectionRule(cmd *cobra.Command, cfg *viper.Viper, name, key, value string) (wholeoverride.DetectionRule, error) {
+	if cmd.Flags().Changed(name) {
+		return wholeoverride.ParseDetectionRule(value)
+	}
+	if spec, ok := cfg.Get(key).(string); ok {
+		return wholeoverride.ParseDetectionRule(spec)
+	}
+
+	var rule wholeoverride.DetectionRule
+	if err := cfg.UnmarshalKey(key, &rule); err != nil {
+		return wholeoverride.DetectionRule{}, err
+	}
+	return rule, rule.Validate()
+}
+
+// configuredTargets returns the targets declared under the targets key,
+// ordered by name.
+func configuredTargets(cfg *viper.Viper) ([]wholeoverride.Target, error) {
+	var byName map[string]wholeoverride.Target
+	if err := cfg.UnmarshalKey("targets", &byName); err != nil {
+		return nil, fmt.Errorf("invalid targets in config: %w", err)
+	}
+
+	targets := make([]wholeoverride.Target, 0, len(byName))
+	for name, target := range byName {
+		target.Name = name
+		targets = append(targets, target)
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		return targets[i].Name < targets[j].Name
+	})
+
+	return targets, nil
+}
+
+func findTarget(targets []wholeoverride.Target, name string) (wholeoverride.Target, error) {
+	names := make([]string, 0, len(targets))
+	for _, target := range targets {
+		if strings.EqualFold(target.Name, name) {
+			return target, nil
+		}
+		names = append(names, target.Name)
+	}
+	if len(names) == 0 {
+		return wholeoverride.Target{}, fmt.Errorf("unknown target %q, no targets are configured", name)
+	}
+	return wholeoverride.Target{}, fmt.Errorf("unknown target %q, configured targets are %s",
+		name, strings.Join(names, ", "))
+}
@@ -1,37 +1,343 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/go-logr/logr"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 
-	"github.com/gkwa/wholeoverride/core"
+	"github.com/gkwa/wholeoverride/wholeoverride"
 )
 
 var (
-	baseDir string
-	format  string
+	baseDir     string
+	format      string
+	watch       bool
+	debounce    time.Duration
+	noCache     bool
+	jobs        int
+	tmplPath    string
+	htmlDir     string
+	output      string
+	filterExpr  string
+	sortSpec    string
+	columns     string
+	groupBy     string
+	targetName  string
+	strict      bool
+	creatorDir  string
+	missingPol  string
+	placeholder string
+	recipeRule  string
+	creatorRule string
+	include     []string
+	exclude     []string
+	formatOpts  map[string]string
+	dryRun      bool
+	check       bool
 )
 
 var generateCmd = &cobra.Command{
 	Use:   "generate",
 	Short: "Generate a markdown file from recipe files",
-	Long:  `Generate a markdown file containing recipe and creator information from a directory of markdown files.`,
-	Run: func(cmd *cobra.Command, args []string) {
+	Long: `Generate a markdown file containing recipe and creator information from a directory of markdown files.
+
+When the config file declares targets, every target is built unless --target
+selects a single one. Otherwise a single index is built from the flags, which
+are refused when the config file declares targets.
+
+Recipes that cannot be parsed or whose creator cannot be read are left out of
+the index and reported. With --strict the run fails instead and nothing is
+written.
+
+Recipes none of whose creators can be found are dropped by default.
+--missing-creator include-unknown lists them under "Unknown creator" instead,
+and --missing-creator placeholder also shows --placeholder-image for it.
+
+Notes are recipes when their frontmatter has filetype: recipe, unless
+--recipe-rule says otherwise. A rule lists frontmatter key=value pairs, #tags
+and path globs separated by commas, any of which has to match, or all of them
+when the rule starts with "all:". --creator-rule limits the notes creator
+links may resolve to in the same way.
+
+--format list shows every available format with the options it accepts,
+which --format-option sets, e.g. --format-option group_by=tag.
+
+--dry-run prints what would change as a unified diff instead of writing
+anything, and --check exits with a non-zero status when any output is out of
+date, e.g. in a pre-commit hook. Neither writes the cache, and outputs going
+to standard output are skipped.
+
+Files listed in a .wholeoverrideignore file in the base directory, written
+like a .gitignore file, are not scanned, and neither are the .git, .trash and
+.obsidian directories or the files the targets write to. --exclude adds more
+patterns and --include limits the scan to the files matching its patterns.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if format == "list" {
+			return listFormats(cmd.OutOrStdout())
+		}
+		if baseDir == "" {
+			return fmt.Errorf(`required flag(s) "basedir" not set`)
+		}
+
 		logger := LoggerFrom(cmd.Context())
 		logger.Info("Running generate command")
 
-		if err := core.GenerateMarkdownWithFormat(logger, baseDir, format); err != nil {
-			logger.Error(err, "Failed to generate markdown")
+		cfg, err := loadVaultConfig(logger, baseDir)
+		if err != nil {
+			return err
 		}
+
+		targets, err := generateTargets(cmd, logger, cfg)
+		if err != nil {
+			return fmt.Errorf("failed to determine what to generate: %w", err)
+		}
+
+		policy, err := wholeoverride.ParseMissingCreatorPolicy(
+			stringSetting(cmd, cfg, "missing-creator", "missing_creator", missingPol))
+		if err != nil {
+			return err
+		}
+
+		rules, err := detection(cmd, cfg, recipeRule, creatorRule)
+		if err != nil {
+			return err
+		}
+
+		opts := wholeoverride.Options{
+			BaseDir:          baseDir,
+			Logger:           logger,
+			NoCache:          noCache,
+			Jobs:             jobs,
+			Strict:           strict,
+			CreatorsDir:      creatorsDir(cmd, cfg, creatorDir),
+			Detection:        rules,
+			Include:          stringSliceSetting(cmd, cfg, "include", "include", include),
+			Exclude:          stringSliceSetting(cmd, cfg, "exclude", "exclude", exclude),
+			MissingCreator:   policy,
+			PlaceholderImage: stringSetting(cmd, cfg, "placeholder-image", "placeholder_image", placeholder),
+		}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		if dryRun || check {
+			return previewTargets(ctx, cmd.OutOrStdout(), opts, targets)
+		}
+
+		if watch {
+			if err := wholeoverride.Watch(ctx, opts, debounce, targets...); err != nil {
+				return fmt.Errorf("failed to watch for changes: %w", err)
+			}
+			return nil
+		}
+
+		return wholeoverride.Build(ctx, opts, targets...)
 	},
 }
 
+// previewTargets prints the diff of what building targets would change
+// to w with --dry-run and fails with --check when anything would.
+func previewTargets(ctx context.Context, w io.Writer, opts wholeoverride.Options, targets []wholeoverride.Target) error {
+	changes, err := wholeoverride.Preview(ctx, opts, targets...)
+	if dryRun {
+		if err := wholeoverride.WriteDiff(w, opts.BaseDir, changes); err != nil {
+			return err
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if check && len(changes) > 0 {
+		paths := make([]string, len(changes))
+		for i, change := range changes {
+			paths[i] = change.Path
+		}
+		return fmt.Errorf("%d file(s) out of date: %s", len(changes), strings.Join(paths, ", "))
+	}
+	return nil
+}
+
+// targetFlags describe the single target built when the config file
+// declares none.
+var targetFlags = []string{
+	"format", "template", "filter", "sort", "columns", "group-by", "format-option", "output", "output-dir",
+}
+
+// generateTargets returns the configured targets, narrowed down by --target,
+// or a single target described by the command line flags. Flags describing
+// a target are refused when configured targets are built, rather than
+// ignored.
+func generateTargets(cmd *cobra.Command, logger logr.Logger, cfg *viper.Viper) ([]wholeoverride.Target, error) {
+	targets, err := configuredTargets(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(targets) > 0 {
+		var changed []string
+		for _, name := range targetFlags {
+			if cmd.Flags().Changed(name) {
+				changed = append(changed, "--"+name)
+			}
+		}
+		if len(changed) > 0 {
+			return nil, fmt.Errorf("%s cannot be used when the config file declares targets, set them on the targets instead",
+				strings.Join(changed, ", "))
+		}
+	}
+
+	if targetName != "" {
+		target, err := findTarget(targets, targetName)
+		if err != nil {
+			return nil, err
+		}
+		return []wholeoverride.Target{target}, nil
+	}
+
+	if len(targets) > 0 {
+		logger.V(1).Info("Building configured targets", "count", len(targets))
+		return targets, nil
+	}
+
+	target, err := flagTarget()
+	if err != nil {
+		return nil, err
+	}
+	return []wholeoverride.Target{target}, nil
+}
+
+func flagTarget() (wholeoverride.Target, error) {
+	target := wholeoverride.Target{
+		Name:    "default",
+		Format:  format,
+		Filter:  filterExpr,
+		Sort:    sortSpec,
+		Columns: columns,
+		GroupBy: groupBy,
+		Options: formatOpts,
+	}
+	if tmplPath != "" {
+		target.Format = ""
+		target.Template = absPath(tmplPath)
+	}
+
+	switch {
+	case format == "html":
+		if htmlDir == "" {
+			return wholeoverride.Target{}, fmt.Errorf("the html format requires --output-dir")
+		}
+		target.Output = absPath(htmlDir)
+	case output != "":
+		target.Output = absPath(output)
+	case format == "json" || format == "ndjson":
+		target.Output = wholeoverride.StdoutPath
+	default:
+		target.Output = wholeoverride.IndexFileName
+	}
+
+	return target, nil
+}
+
+// listFormats writes the registered formats and their options to w,
+// followed by the html format, which writes a site rather than an index.
+func listFormats(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, f := range wholeoverride.Formats() {
+		fmt.Fprintf(tw, "%s\t%s\n", f.Name, f.Description)
+		for _, option := range f.Options {
+			fmt.Fprintf(tw, "  %s\t%s\n", option.Name, option.Description)
+		}
+	}
+	fmt.Fprintf(tw, "html\ta static site written to --output-dir\n")
+	return tw.Flush()
+}
+
+// absPath resolves paths given on the command line against the working
+// directory, because relative target paths are taken relative to the base
+// directory.
+func absPath(path string) string {
+	if path == wholeoverride.StdoutPath {
+		return path
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	return abs
+}
+
 func init() {
 	rootCmd.AddCommand(generateCmd)
 	generateCmd.Flags().
 		StringVar(&baseDir, "basedir", "", "Base directory containing markdown files")
 	generateCmd.Flags().
-		StringVar(&format, "format", "sections", "Output format (sections or table)")
-	if err := generateCmd.MarkFlagRequired("basedir"); err != nil {
-		panic(err)
-	}
+		StringVar(&format, "format", "sections", "Output format (sections, table, html, json, ndjson or another registered format), or list to show them all")
+	generateCmd.Flags().
+		StringToStringVar(&formatOpts, "format-option", nil, "Options of the format as listed by --format list, e.g. group_by=tag")
+	generateCmd.Flags().
+		BoolVar(&watch, "watch", false, "Keep running and regenerate the index when markdown files change")
+	generateCmd.Flags().
+		DurationVar(&debounce, "debounce", wholeoverride.DefaultDebounce, "Quiet period to wait for after a change before regenerating in watch mode")
+	generateCmd.Flags().
+		BoolVar(&noCache, "no-cache", false, "Parse every file instead of reusing results cached in "+wholeoverride.CacheDirName)
+	generateCmd.Flags().
+		IntVar(&jobs, "jobs", runtime.GOMAXPROCS(0), "Number of files to parse concurrently")
+	generateCmd.Flags().
+		StringVarP(&output, "output", "o", "", "File to write the index to, or - for stdout (default is recipeindex.md in the base directory, stdout for json and ndjson)")
+	generateCmd.Flags().
+		StringVar(&htmlDir, "output-dir", "", "Directory to write the static site to when using the html format")
+	generateCmd.Flags().
+		StringVar(&tmplPath, "template", "", "Render the index with a Go text/template file instead of a built-in format")
+	generateCmd.Flags().
+		StringVar(&filterExpr, "filter", "", `Only include recipes matching this filter expression, e.g. 'tags contains vegan and total_time <= 30m'`)
+	generateCmd.Flags().
+		StringVar(&sortSpec, "sort", wholeoverride.DefaultSort, "Sort keys (title, creator, date-added, mtime, rating, total-time), e.g. rating:desc,title")
+	generateCmd.Flags().
+		StringVar(&columns, "columns", "", "Extra metadata columns for the sections and table formats, e.g. cuisine,total_time,rating")
+	generateCmd.Flags().
+		StringVar(&groupBy, "group-by", "", "Group the sections and table formats by creator, tag, cuisine, course, letter or year-added")
+	generateCmd.Flags().
+		StringVar(&targetName, "target", "", "Build only this target from the config file")
+	generateCmd.Flags().
+		StringVar(&creatorDir, "creators-dir", "", "Only resolve creators to notes below this directory of the base directory (overrides creators_dir in the config)")
+	generateCmd.Flags().
+		StringVar(&recipeRule, "recipe-rule", "", "Which notes are recipes, e.g. 'type=recipe,#recipe,Recipes/' (overrides recipe_rule in the config, default filetype=recipe)")
+	generateCmd.Flags().
+		StringVar(&creatorRule, "creator-rule", "", "Which notes creator links may resolve to, e.g. 'People/' (overrides creator_rule in the config, default any note)")
+	generateCmd.Flags().
+		StringSliceVar(&include, "include", nil, "Only scan files matching these gitignore style patterns, e.g. 'Recipes/' (overrides include in the config)")
+	generateCmd.Flags().
+		StringSliceVar(&exclude, "exclude", nil, "Skip files matching these gitignore style patterns in addition to "+wholeoverride.IgnoreFileName+", e.g. '*.draft.md' (overrides exclude in the config)")
+	generateCmd.Flags().
+		StringVar(&missingPol, "missing-creator", string(wholeoverride.MissingCreatorDrop), "What to do with recipes whose creators are not found: drop, include-unknown or placeholder (overrides missing_creator in the config)")
+	generateCmd.Flags().
+		StringVar(&placeholder, "placeholder-image", "", "Image shown for the unknown creator with --missing-creator placeholder (overrides placeholder_image in the config)")
+	generateCmd.Flags().
+		BoolVar(&strict, "strict", false, "Fail without writing anything if any recipe had to be dropped from the index")
+	generateCmd.Flags().
+		BoolVar(&dryRun, "dry-run", false, "Print what would change as a unified diff instead of writing it")
+	generateCmd.Flags().
+		BoolVar(&check, "check", false, "Exit with a non-zero status if any output is out of date, without writing it")
+	generateCmd.MarkFlagsMutuallyExclusive("format", "template")
+	generateCmd.MarkFlagsMutuallyExclusive("watch", "dry-run")
+	generateCmd.MarkFlagsMutuallyExclusive("watch", "check")
+	generateCmd.MarkFlagsMutuallyExclusive("target", "format")
+	generateCmd.MarkFlagsMutuallyExclusive("target", "template")
+	generateCmd.MarkFlagsMutuallyExclusive("target", "columns")
+	generateCmd.MarkFlagsMutuallyExclusive("target", "group-by")
+	generateCmd.MarkFlagsMutuallyExclusive("target", "format-option")
 }
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/spf13/viper"
+)
+
+func TestGenerateTargets_FlagsWithConfiguredTargets(t *testing.T) {
+	setFlag := func(t *testing.T, name, value string) {
+		t.Helper()
+		flag := generateCmd.Flags().Lookup(name)
+		old := flag.Value.String()
+		if err := flag.Value.Set(value); err != nil {
+			t.Fatal(err)
+		}
+		flag.Changed = true
+		t.Cleanup(func() {
+			_ = flag.Value.Set(old)
+			flag.Changed = false
+		})
+	}
+
+	withTargets := viper.New()
+	withTargets.Set("targets", map[string]any{
+		"desserts": map[string]any{"output": "Desserts.md", "filter": "course = dessert"},
+	})
+
+	t.Run("configured targets", func(t *testing.T) {
+		targets, err := generateTargets(generateCmd, testr.New(t), withTargets)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(targets) != 1 || targets[0].Filter != "course = dessert" {
+			t.Errorf("Expected the desserts target, got %+v", targets)
+		}
+	})
+
+	t.Run("target flags refused", func(t *testing.T) {
+		setFlag(t, "filter", "tags contains vegan")
+		setFlag(t, "sort", "rating:desc")
+		_, err := generateTargets(generateCmd, testr.New(t), withTargets)
+		if err == nil || !strings.Contains(err.Error(), "--filter, --sort") {
+			t.Errorf("Expected --filter and --sort to be refused, got %v", err)
+		}
+	})
+
+	t.Run("flag target without configured targets", func(t *testing.T) {
+		setFlag(t, "filter", "tags contains vegan")
+		targets, err := generateTargets(generateCmd, testr.New(t), viper.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(targets) != 1 || targets[0].Filter != "tags contains vegan" {
+			t.Errorf("Expected a target built from the flags, got %+v", targets)
+		}
+	})
+}
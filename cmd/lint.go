@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gkwa/wholeoverride/wholeoverride"
+)
+
+var (
+	lintBaseDir        string
+	lintFormat         string
+	lintKnownFiletypes []string
+	lintCreatorsDir    string
+	lintRecipeRule     string
+	lintCreatorRule    string
+	lintInclude        []string
+	lintExclude        []string
+)
+
+var lintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Check recipe and creator frontmatter for problems",
+	Long: `Check the frontmatter of every recipe and the creator notes they reference,
+reporting each problem as a file:line diagnostic. Exits with a non-zero status
+when any error is found.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		logger := LoggerFrom(cmd.Context())
+		logger.V(1).Info("Running lint command")
+
+		cfg, err := loadVaultConfig(logger, lintBaseDir)
+		if err != nil {
+			return err
+		}
+
+		rules, err := detection(cmd, cfg, lintRecipeRule, lintCreatorRule)
+		if err != nil {
+			return err
+		}
+
+		diagnostics, err := wholeoverride.Lint(cmd.Context(), wholeoverride.Options{
+			BaseDir:        lintBaseDir,
+			Logger:         logger,
+			CreatorsDir:    creatorsDir(cmd, cfg, lintCreatorsDir),
+			Detection:      rules,
+			Include:        stringSliceSetting(cmd, cfg, "include", "include", lintInclude),
+			Exclude:        stringSliceSetting(cmd, cfg, "exclude", "exclude", lintExclude),
+			KnownFiletypes: lintKnownFiletypes,
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := wholeoverride.WriteDiagnostics(cmd.OutOrStdout(), diagnostics, lintFormat); err != nil {
+			return err
+		}
+
+		errorCount := 0
+		for _, d := range diagnostics {
+			if d.Severity == wholeoverride.SeverityError {
+				errorCount++
+			}
+		}
+		logger.Info("Lint summary",
+			"errors", errorCount, "warnings", len(diagnostics)-errorCount)
+
+		if wholeoverride.HasErrors(diagnostics) {
+			return fmt.Errorf("lint found %d error(s)", errorCount)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+	lintCmd.Flags().
+		StringVar(&lintBaseDir, "basedir", "", "Base directory containing markdown files")
+	lintCmd.Flags().
+		StringVar(&lintFormat, "format", "text", "Output format (text, json or github)")
+	lintCmd.Flags().
+		StringSliceVar(&lintKnownFiletypes, "known-filetype", nil, "Additional filetype values that are not reported as unknown")
+	lintCmd.Flags().
+		StringVar(&lintCreatorsDir, "creators-dir", "", "Only resolve creators to notes below this directory of the base directory (overrides creators_dir in the config)")
+	lintCmd.Flags().
+		StringVar(&lintRecipeRule, "recipe-rule", "", "Which notes are recipes, see generate --help (overrides recipe_rule in the config)")
+	lintCmd.Flags().
+		StringVar(&lintCreatorRule, "creator-rule", "", "Which notes creator links may resolve to, see generate --help (overrides creator_rule in the config)")
+	lintCmd.Flags().
+		StringSliceVar(&lintInclude, "include", nil, "Only lint files matching these gitignore style patterns (overrides include in the config)")
+	lintCmd.Flags().
+		StringSliceVar(&lintExclude, "exclude", nil, "Skip files matching these gitignore style patterns in addition to "+wholeoverride.IgnoreFileName+" (overrides exclude in the config)")
+	if err := lintCmd.MarkFlagRequired("basedir"); err != nil {
+		panic(err)
+	}
+}
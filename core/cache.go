@@ -0,0 +1,385 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"github.com/gkwa/wholeoverride/version"
+)
+
+const (
+	CacheDirName  = ".wholeoverride"
+	cacheFileName = "cache.json"
+
+	// cacheSchemaVersion must be bumped whenever the layout of CacheEntry or
+	// the parsed structs stored in it changes.
+	cacheSchemaVersion = 13
+)
+
+type CacheEntry struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mtime"`
+	Digest  string    `json:"sha256"`
+	// RecipeParsed is set once the file has been parsed as a note; Recipe
+	// stays nil for files that turned out not to be recipes. Aliases are
+	// recorded for every note so creators can be linked to by alias.
+	RecipeParsed bool        `json:"recipeParsed"`
+	Recipe       *RecipeInfo `json:"recipe,omitempty"`
+	Aliases      []string    `json:"aliases,omitempty"`
+	// CreatorNote is set when creator links may resolve to the note.
+	// Detection identifies the rules Recipe and CreatorNote were decided
+	// with.
+	CreatorNote bool         `json:"creatorNote,omitempty"`
+	Detection   string       `json:"detection,omitempty"`
+	Creator     *CreatorInfo `json:"creator,omitempty"`
+}
+
+// Cache remembers the parse results of markdown files between runs so that
+// files whose size, mtime or content digest did not change are not parsed
+// again. A nil *Cache is valid and parses every file.
+type Cache struct {
+	Version string `json:"version"`
+	// Entries are keyed by the slash separated path of the note relative
+	// to baseDir, so that runs naming the vault by a relative or an
+	// absolute path share them.
+	Entries map[string]*CacheEntry `json:"entries"`
+
+	path    string
+	baseDir string
+	writer  Writer
+	mu      sync.Mutex
+	dirty   bool
+	seen    map[string]bool
+}
+
+func CachePath(baseDir string) string {
+	return filepath.Join(baseDir, CacheDirName, cacheFileName)
+}
+
+func cacheVersion() string {
+	return fmt.Sprintf("%d:%s", cacheSchemaVersion, toolVersion())
+}
+
+// toolVersion identifies the build of the tool: the version stamped in by
+// the release build, else the module version or VCS revision Go records in
+// binaries built with go build or go install.
+func toolVersion() string {
+	if version.Version != "" {
+		return version.Version
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	return buildVersion(info)
+}
+
+func buildVersion(info *debug.BuildInfo) string {
+	if v := info.Main.Version; v != "" && v != "(devel)" {
+		return v
+	}
+
+	var revision, modified string
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			modified = setting.Value
+		}
+	}
+	if revision != "" && modified == "true" {
+		return revision + "+dirty"
+	}
+	return revision
+}
+
+func newCache(baseDir string, w Writer) *Cache {
+	return &Cache{
+		Version: cacheVersion(),
+		Entries: make(map[string]*CacheEntry),
+		path:    CachePath(baseDir),
+		baseDir: baseDir,
+		writer:  w,
+		seen:    make(map[string]bool),
+	}
+}
+
+// LoadCache reads the cache for baseDir. A missing, unreadable or outdated
+// cache file results in an empty cache rather than an error.
+func LoadCache(logger logr.Logger, baseDir string) *Cache {
+	return loadCache(logger, baseDir, OSWriter{})
+}
+
+// loadCache reads the cache for baseDir through w, which the cache is
+// also saved with, like the other files the tool writes.
+func loadCache(logger logr.Logger, baseDir string, w Writer) *Cache {
+	cache := newCache(baseDir, w)
+	path := cache.path
+
+	content, err := w.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			logger.Error(err, "Failed to read cache, starting with an empty cache", "path", path)
+		}
+		return cache
+	}
+
+	var stored Cache
+	if err := json.Unmarshal(content, &stored); err != nil {
+		logger.Error(err, "Failed to decode cache, starting with an empty cache", "path", path)
+		return cache
+	}
+
+	if stored.Version != cache.Version {
+		logger.V(1).Info("Cache was written by a different version, discarding",
+			"path", path, "cacheVersion", stored.Version, "currentVersion", cache.Version)
+		return cache
+	}
+
+	if stored.Entries != nil {
+		cache.Entries = stored.Entries
+	}
+
+	logger.V(1).Info("Loaded cache", "path", path, "entries", len(cache.Entries))
+	return cache
+}
+
+// Save writes the cache back, dropping entries for files that were
+// not looked at during this run.
+func (c *Cache) Save(logger logr.Logger) error {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.Entries {
+		if !c.seen[key] {
+			logger.V(2).Info("Pruning cache entry", "path", key)
+			delete(c.Entries, key)
+			c.dirty = true
+		}
+	}
+
+	if !c.dirty {
+		logger.V(1).Info("Cache unchanged, skipping write", "path", c.path)
+		return nil
+	}
+
+	content, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache: %w", err)
+	}
+
+	if err := c.writer.WriteFile(c.path, content); err != nil {
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+
+	c.dirty = false
+	logger.V(1).Info("Saved cache", "path", c.path, "entries", len(c.Entries))
+	return nil
+}
+
+func ClearCache(logger logr.Logger, baseDir string) error {
+	path := CachePath(baseDir)
+	err := os.Remove(path)
+	if errors.Is(err, os.ErrNotExist) {
+		logger.V(1).Info("No cache to clear", "path", path)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to remove cache: %w", err)
+	}
+	logger.Info("Cleared cache", "path", path)
+	return nil
+}
+
+// lookup returns the entry for path, replacing it with a fresh one when the
+// file changed since it was cached. The file content is returned whenever it
+// had to be read, so that callers don't need to read it a second time.
+func (c *Cache) lookup(logger logr.Logger, v vault, path string) (*CacheEntry, []byte, error) {
+	info, err := v.stat(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key := c.key(path)
+	c.mu.Lock()
+	c.seen[key] = true
+	entry := c.Entries[key]
+	// File systems such as fstest.MapFS and zip archives may report no
+	// mtime, which says nothing about whether the file changed.
+	if entry != nil && !info.ModTime().IsZero() &&
+		entry.Size == info.Size() && entry.ModTime.Equal(info.ModTime()) {
+		c.mu.Unlock()
+		logger.V(2).Info("Cache hit", "path", path, "reason", "size and mtime unchanged")
+		return entry, nil, nil
+	}
+	c.mu.Unlock()
+
+	content, err := v.readFile(logger, path)
+	if err != nil {
+		return nil, nil, err
+	}
+	digest := calculateDigest(content)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.dirty = true
+	entry = c.Entries[key]
+	if entry != nil && entry.Digest == digest {
+		logger.V(2).Info("Cache hit", "path", path, "reason", "digest unchanged")
+		entry.Size = info.Size()
+		entry.ModTime = info.ModTime()
+		return entry, content, nil
+	}
+
+	logger.V(2).Info("Cache miss", "path", path)
+	entry = &CacheEntry{
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+		Digest:  digest,
+	}
+	c.Entries[key] = entry
+	return entry, content, nil
+}
+
+// key returns the key of the entry for the note at path.
+func (c *Cache) key(path string) string {
+	rel, err := filepath.Rel(c.baseDir, path)
+	if err != nil {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+func (c *Cache) ParseRecipeFile(logger logr.Logger, path string) (*RecipeInfo, error) {
+	note, err := c.parseNote(logger, newVault(filepath.Dir(path), nil), noteDetector{}, path)
+	return note.recipe, err
+}
+
+// parseNote parses the note at path in v, telling recipes and creator notes
+// apart with detector. Cached results are only reused when they were
+// detected with the same rules.
+func (c *Cache) parseNote(logger logr.Logger, v vault, detector noteDetector, path string) (parsedNote, error) {
+	if c == nil {
+		content, err := v.readFile(logger, path)
+		if err != nil {
+			return parsedNote{}, fmt.Errorf("failed to read file: %w", err)
+		}
+		note, err := parseNoteContent(logger, detector, path, content)
+		if note.recipe != nil {
+			if info, statErr := v.stat(path); statErr == nil {
+				note.recipe.ModTime = info.ModTime()
+			}
+		}
+		return note, err
+	}
+
+	entry, content, err := c.lookup(logger, v, path)
+	if err != nil {
+		return parsedNote{}, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	detection := detector.key()
+	c.mu.Lock()
+	parsed := entry.RecipeParsed && entry.Detection == detection
+	cached := parsedNote{recipe: copyRecipe(entry.Recipe), aliases: entry.Aliases, creator: entry.CreatorNote}
+	modTime := entry.ModTime
+	c.mu.Unlock()
+	if parsed {
+		if cached.recipe != nil {
+			cached.recipe.Path = path
+			cached.recipe.ModTime = modTime
+		}
+		return cached, nil
+	}
+
+	if content == nil {
+		if content, err = v.readFile(logger, path); err != nil {
+			return parsedNote{}, fmt.Errorf("failed to read file: %w", err)
+		}
+	}
+
+	note, err := parseNoteContent(logger, detector, path, content)
+	if err != nil {
+		return parsedNote{}, err
+	}
+	if note.recipe != nil {
+		note.recipe.ModTime = modTime
+	}
+
+	c.mu.Lock()
+	entry.RecipeParsed = true
+	entry.Detection = detection
+	entry.Recipe = copyRecipe(note.recipe)
+	entry.Aliases = note.aliases
+	entry.CreatorNote = note.creator
+	c.dirty = true
+	c.mu.Unlock()
+	return note, nil
+}
+
+func (c *Cache) ParseCreatorFile(
+	logger logr.Logger,
+	baseDir, creatorName string,
+) (*CreatorInfo, error) {
+	return c.parseCreatorPath(logger, newVault(baseDir, nil), creatorPath(baseDir, creatorName), creatorName)
+}
+
+func (c *Cache) parseCreatorPath(logger logr.Logger, v vault, path, creatorName string) (*CreatorInfo, error) {
+	if c == nil {
+		return parseCreatorPath(logger, v, path, creatorName)
+	}
+
+	entry, content, err := c.lookup(logger, v, path)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	cached := entry.Creator
+	c.mu.Unlock()
+	if cached != nil {
+		creator := *cached
+		creator.Path = path
+		creator.Name = creatorName
+		return &creator, nil
+	}
+
+	if content == nil {
+		if content, err = v.readFile(logger, path); err != nil {
+			return nil, err
+		}
+	}
+
+	creator := parseCreatorContent(logger, path, creatorName, content)
+
+	stored := *creator
+	c.mu.Lock()
+	entry.Creator = &stored
+	c.dirty = true
+	c.mu.Unlock()
+	return creator, nil
+}
+
+func copyRecipe(recipe *RecipeInfo) *RecipeInfo {
+	if recipe == nil {
+		return nil
+	}
+	c := *recipe
+	c.Frontmatter = normalizeFrontmatter(recipe.Frontmatter)
+	return &c
+}
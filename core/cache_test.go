@@ -0,0 +1,176 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/go-logr/logr/testr"
+)
+
+func TestCache_ParseRecipeFile(t *testing.T) {
+	logger := testr.New(t)
+	baseDir := t.TempDir()
+
+	path := filepath.Join(baseDir, "Pie.md")
+	writeNote := func(creator string, mtime time.Time) {
+		content := "---\nfiletype: recipe\npic: pie.jpg\ncreator: " + creator + "\n---\n"
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mtime := time.Now().Add(-time.Hour)
+	writeNote("Jane", mtime)
+
+	cache := LoadCache(logger, baseDir)
+	recipe, err := cache.ParseRecipeFile(logger, path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if recipe == nil || len(recipe.Creators) != 1 || recipe.Creators[0].Target != "Jane" {
+		t.Fatalf("Expected recipe by Jane, got %+v", recipe)
+	}
+	if err := cache.Save(logger); err != nil {
+		t.Fatalf("Unexpected error saving cache: %v", err)
+	}
+
+	cache = LoadCache(logger, baseDir)
+	if _, ok := cache.Entries["Pie.md"]; !ok {
+		t.Fatalf("Expected cache entry for Pie.md after reload, got %v", cache.Entries)
+	}
+
+	recipe.Slug = "mutated"
+	recipe, err = cache.ParseRecipeFile(logger, path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if recipe.Slug != "" {
+		t.Errorf("Cached recipe was mutated by caller, got slug %q", recipe.Slug)
+	}
+
+	writeNote("John", mtime.Add(time.Minute))
+	recipe, err = cache.ParseRecipeFile(logger, path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if recipe.Creators[0].Target != "John" {
+		t.Errorf("Expected changed file to be parsed again, got creators %v", recipe.Creators)
+	}
+}
+
+func TestScanRecipes_RelativeAndAbsoluteBaseDir(t *testing.T) {
+	logger := testr.New(t)
+	dir := t.TempDir()
+	t.Chdir(dir)
+	writeTestFile(t, filepath.Join("vault", "Recipes", "Pie.md"),
+		"---\nfiletype: recipe\npic: pie.jpg\ncreator: \"[[Sam]]\"\n---\n")
+	writeTestFile(t, filepath.Join("vault", "People", "Sam.md"), "---\npic: sam.jpg\n---\n")
+
+	if _, _, err := ScanRecipes(t.Context(), logger, GenerateOptions{BaseDir: "vault"}); err != nil {
+		t.Fatalf("ScanRecipes failed: %v", err)
+	}
+	cachePath := CachePath("vault")
+	before, err := os.ReadFile(cachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	baseDir := filepath.Join(dir, "vault")
+	recipes, creators, err := ScanRecipes(t.Context(), logger, GenerateOptions{BaseDir: baseDir})
+	if err != nil {
+		t.Fatalf("ScanRecipes failed: %v", err)
+	}
+	after, err := os.ReadFile(cachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(before) != string(after) {
+		t.Errorf("Expected the absolute base directory to reuse the cache, got\n%s\nthen\n%s", before, after)
+	}
+
+	if want := filepath.Join(baseDir, "Recipes", "Pie.md"); len(recipes) != 1 || recipes[0].Path != want {
+		t.Errorf("Expected the recipe at %s, got %+v", want, recipes)
+	}
+	if want := filepath.Join(baseDir, "People", "Sam.md"); creators["Sam"] == nil || creators["Sam"].Path != want {
+		t.Errorf("Expected the creator at %s, got %+v", want, creators["Sam"])
+	}
+}
+
+func TestScanRecipes_InMemoryVaults(t *testing.T) {
+	t.Chdir(t.TempDir())
+	logger := testr.New(t)
+	vault := func(pic string) fstest.MapFS {
+		return fstest.MapFS{
+			"Pie.md": {Data: []byte("---\nfiletype: recipe\npic: " + pic + "\ncreator: \"[[Sam]]\"\n---\n")},
+			"Sam.md": {Data: []byte("---\npic: sam.jpg\n---\n")},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		writer Writer
+	}{
+		{name: "without writer"},
+		{name: "with writer", writer: NewMemWriter()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, pic := range []string{"a.jpg", "b.jpg"} {
+				opts := GenerateOptions{BaseDir: "zipvault", FS: vault(pic), Writer: tt.writer}
+				recipes, _, err := ScanRecipes(t.Context(), logger, opts)
+				if err != nil {
+					t.Fatalf("ScanRecipes failed: %v", err)
+				}
+				if len(recipes) != 1 || recipes[0].ImageURL != pic {
+					t.Fatalf("Expected the recipe with %s, got %+v", pic, recipes)
+				}
+			}
+			if _, err := os.Stat("zipvault"); !os.IsNotExist(err) {
+				t.Errorf("Did not expect anything written to disk, got %v", err)
+			}
+		})
+	}
+}
+
+func TestBuildVersion(t *testing.T) {
+	tests := []struct {
+		name string
+		info debug.BuildInfo
+		want string
+	}{
+		{
+			name: "module version",
+			info: debug.BuildInfo{Main: debug.Module{Version: "v1.2.3"}},
+			want: "v1.2.3",
+		},
+		{
+			name: "vcs revision",
+			info: debug.BuildInfo{Main: debug.Module{Version: "(devel)"}, Settings: []debug.BuildSetting{
+				{Key: "vcs.revision", Value: "abc123"}, {Key: "vcs.modified", Value: "false"},
+			}},
+			want: "abc123",
+		},
+		{
+			name: "modified checkout",
+			info: debug.BuildInfo{Settings: []debug.BuildSetting{
+				{Key: "vcs.revision", Value: "abc123"}, {Key: "vcs.modified", Value: "true"},
+			}},
+			want: "abc123+dirty",
+		},
+		{name: "nothing recorded", info: debug.BuildInfo{Main: debug.Module{Version: "(devel)"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildVersion(&tt.info); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package core
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Column is an extra column of recipe metadata shown by the sections and
+// table formats.
+type Column string
+
+const (
+	ColumnTags      Column = "tags"
+	ColumnCuisine   Column = "cuisine"
+	ColumnCourse    Column = "course"
+	ColumnPrepTime  Column = "prep_time"
+	ColumnCookTime  Column = "cook_time"
+	ColumnTotalTime Column = "total_time"
+	ColumnServings  Column = "servings"
+	ColumnRating    Column = "rating"
+	ColumnSource    Column = "source"
+	ColumnDateAdded Column = "date_added"
+)
+
+type columnSpec struct {
+	header string
+	value  func(recipe *RecipeInfo) string
+}
+
+var columnSpecs = map[Column]columnSpec{
+	ColumnTags: {"Tags", func(r *RecipeInfo) string {
+		tags := make([]string, len(r.Tags))
+		for i, tag := range r.Tags {
+			tags[i] = "#" + tag
+		}
+		return strings.Join(tags, " ")
+	}},
+	ColumnCuisine:   {"Cuisine", func(r *RecipeInfo) string { return r.Cuisine }},
+	ColumnCourse:    {"Course", func(r *RecipeInfo) string { return r.Course }},
+	ColumnPrepTime:  {"Prep Time", func(r *RecipeInfo) string { return formatDuration(r.PrepTime) }},
+	ColumnCookTime:  {"Cook Time", func(r *RecipeInfo) string { return formatDuration(r.CookTime) }},
+	ColumnTotalTime: {"Total Time", func(r *RecipeInfo) string { return formatDuration(r.TotalTime) }},
+	ColumnServings: {"Servings", func(r *RecipeInfo) string {
+		if r.Servings == 0 {
+			return ""
+		}
+		return strconv.FormatFloat(r.Servings, 'f', -1, 64)
+	}},
+	ColumnRating: {"Rating", func(r *RecipeInfo) string {
+		if r.Rating == 0 {
+			return ""
+		}
+		return strconv.FormatFloat(r.Rating, 'f', -1, 64) + "/" + strconv.Itoa(MaxRating)
+	}},
+	ColumnSource: {"Source", func(r *RecipeInfo) string {
+		u, err := url.Parse(r.Source)
+		if r.Source == "" || err != nil {
+			return ""
+		}
+		return fmt.Sprintf("[%s](%s)", strings.TrimPrefix(u.Host, "www."), r.Source)
+	}},
+	ColumnDateAdded: {"Date Added", func(r *RecipeInfo) string {
+		if r.DateAdded.IsZero() {
+			return ""
+		}
+		return r.DateAdded.Format("2006-01-02")
+	}},
+}
+
+// columnOrder lists every column in the order they are documented.
+var columnOrder = []Column{
+	ColumnTags, ColumnCuisine, ColumnCourse, ColumnPrepTime, ColumnCookTime,
+	ColumnTotalTime, ColumnServings, ColumnRating, ColumnSource, ColumnDateAdded,
+}
+
+// ParseColumns parses a comma separated list of column names such as
+// "cuisine,total_time,rating". An empty spec selects no columns.
+func ParseColumns(spec string) ([]Column, error) {
+	var columns []Column
+	for _, part := range strings.Split(spec, ",") {
+		name := Column(strings.ToLower(strings.TrimSpace(part)))
+		if name == "" {
+			continue
+		}
+		if _, ok := columnSpecs[name]; !ok {
+			names := make([]string, len(columnOrder))
+			for i, c := range columnOrder {
+				names[i] = string(c)
+			}
+			return nil, fmt.Errorf("unknown column %q, expected one of %s", name, strings.Join(names, ", "))
+		}
+		columns = append(columns, name)
+	}
+	return columns, nil
+}
+
+// Header is the title of the column.
+func (c Column) Header() string {
+	return columnSpecs[c].header
+}
+
+// cell renders the value of the column for recipe inside a markdown table.
+func (c Column) cell(recipe *RecipeInfo) string {
+	spec, ok := columnSpecs[c]
+	if !ok {
+		return ""
+	}
+	return strings.ReplaceAll(spec.value(recipe), "|", `\|`)
+}
@@ -8,3 +8,94 @@ func formatImage(name, url string, isRemote bool) string {
 	}
 	return fmt.Sprintf("![[%s]]", url)
 }
+
+// recipeWikilink links to the note of recipe by its file name, showing its
+// title when that differs.
+func recipeWikilink(recipe *RecipeInfo) Wikilink {
+	link := Wikilink{Target: recipe.Name}
+	if recipe.Title != recipe.Name {
+		link.Alias = recipe.Title
+	}
+	return link
+}
+
+// creatorRef is a creator of a recipe together with the link that renders
+// it in the index. The unknown creator has no link.
+type creatorRef struct {
+	*CreatorInfo
+	Link Wikilink
+}
+
+func (r creatorRef) unknown() bool {
+	return r.Link.Target == ""
+}
+
+// tableLink renders the link to the creator for a markdown table cell, or
+// just the name of the unknown creator.
+func (r creatorRef) tableLink() string {
+	if r.unknown() {
+		return r.Name
+	}
+	return r.Link.TableString()
+}
+
+// image renders the image of the creator, which the unknown creator only
+// has with the placeholder policy.
+func (r creatorRef) image() string {
+	if r.unknown() && r.ImageURL == "" {
+		return ""
+	}
+	return formatImage(r.Name, r.ImageURL, r.IsRemoteImage)
+}
+
+// recipeCreators returns the creators of recipe that were found, in the
+// order the recipe lists them. Links that resolve to the same note are only
+// returned once. When none were found, the unknown creator is returned if
+// the missing creator policy kept the recipe.
+func recipeCreators(recipe *RecipeInfo, creators map[string]*CreatorInfo) []creatorRef {
+	refs := make([]creatorRef, 0, len(recipe.Creators))
+	for _, link := range recipe.Creators {
+		creator, ok := creators[link.Target]
+		if !ok || containsCreator(refs, creator) {
+			continue
+		}
+		refs = append(refs, creatorRef{CreatorInfo: creator, Link: creatorWikilink(link, creator)})
+	}
+
+	if unknown, ok := creators[unknownCreatorKey]; ok && len(refs) == 0 {
+		refs = append(refs, creatorRef{CreatorInfo: unknown})
+	}
+	return refs
+}
+
+func containsCreator(refs []creatorRef, creator *CreatorInfo) bool {
+	for _, ref := range refs {
+		if ref.CreatorInfo == creator {
+			return true
+		}
+	}
+	return false
+}
+
+// creatorWikilink links to the note of creator, keeping the heading of the
+// recipe's creator link and showing the same text that link shows.
+func creatorWikilink(recipeLink Wikilink, creator *CreatorInfo) Wikilink {
+	link := Wikilink{Target: creator.Link, Heading: recipeLink.Heading}
+	if link.Target == "" {
+		link.Target = creator.Name
+	}
+	if display := recipeLink.Display(); display != "" && display != link.Name() {
+		link.Alias = display
+	}
+	return link
+}
+
+// creatorNames returns the names the creator links of recipe show, for
+// filtering and sorting before creators are looked up.
+func creatorNames(recipe *RecipeInfo) []string {
+	names := make([]string, len(recipe.Creators))
+	for i, link := range recipe.Creators {
+		names[i] = link.Name()
+	}
+	return names
+}
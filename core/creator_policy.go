@@ -0,0 +1,64 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MissingCreatorPolicy decides what happens to recipes none of whose
+// creators could be found.
+type MissingCreatorPolicy string
+
+const (
+	// MissingCreatorDrop leaves the recipe out of the index.
+	MissingCreatorDrop MissingCreatorPolicy = "drop"
+	// MissingCreatorIncludeUnknown lists the recipe under UnknownCreatorName.
+	MissingCreatorIncludeUnknown MissingCreatorPolicy = "include-unknown"
+	// MissingCreatorPlaceholder lists the recipe under UnknownCreatorName
+	// with a placeholder image.
+	MissingCreatorPlaceholder MissingCreatorPolicy = "placeholder"
+)
+
+// UnknownCreatorName is shown in place of the creators of recipes that are
+// kept by the missing creator policy.
+const UnknownCreatorName = "Unknown creator"
+
+// unknownCreatorKey is the key of the unknown creator in the creators map.
+// It is never the target of a creator link.
+const unknownCreatorKey = ""
+
+// ParseMissingCreatorPolicy parses the name of a policy. An empty name
+// selects MissingCreatorDrop.
+func ParseMissingCreatorPolicy(s string) (MissingCreatorPolicy, error) {
+	switch policy := MissingCreatorPolicy(strings.ToLower(strings.TrimSpace(s))); policy {
+	case "":
+		return MissingCreatorDrop, nil
+	case MissingCreatorDrop, MissingCreatorIncludeUnknown, MissingCreatorPlaceholder:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("invalid missing creator policy %q, expected %s, %s or %s",
+			s, MissingCreatorDrop, MissingCreatorIncludeUnknown, MissingCreatorPlaceholder)
+	}
+}
+
+// unknownCreator returns the creator that recipes kept by policy are listed
+// under, or nil when they are dropped.
+func unknownCreator(policy MissingCreatorPolicy, placeholderImage string) (*CreatorInfo, error) {
+	switch policy {
+	case "", MissingCreatorDrop:
+		return nil, nil
+	case MissingCreatorIncludeUnknown:
+		return &CreatorInfo{Name: UnknownCreatorName}, nil
+	case MissingCreatorPlaceholder:
+		if placeholderImage == "" {
+			return nil, fmt.Errorf("the %s policy requires a placeholder image", MissingCreatorPlaceholder)
+		}
+		return &CreatorInfo{
+			Name:          UnknownCreatorName,
+			ImageURL:      placeholderImage,
+			IsRemoteImage: isRemoteURL(placeholderImage),
+		}, nil
+	default:
+		return nil, fmt.Errorf("invalid missing creator policy %q", policy)
+	}
+}
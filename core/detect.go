@@ -0,0 +1,311 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+// RuleMatch decides whether a DetectionRule needs any or all of its
+// conditions to hold.
+type RuleMatch string
+
+const (
+	RuleMatchAny RuleMatch = "any"
+	RuleMatchAll RuleMatch = "all"
+)
+
+// DetectionRule recognizes notes by their frontmatter, their tags or where
+// they are in the vault. A note matches when any of the conditions holds,
+// or every one of them with RuleMatchAll. The zero rule has no conditions
+// and is replaced by a default wherever it is used.
+type DetectionRule struct {
+	// Frontmatter maps frontmatter keys to the value they must have. A list
+	// matches when any of its items does and "*" matches any value that is
+	// not empty.
+	Frontmatter map[string]string `mapstructure:"frontmatter" json:"frontmatter,omitempty"`
+	// Tags are tags the note must have, either in its tags field or inline
+	// in its text. A tag also matches its nested tags, so recipe matches
+	// #recipe/dessert.
+	Tags []string `mapstructure:"tags" json:"tags,omitempty"`
+	// Paths are globs matched against the path of the note relative to the
+	// base directory, such as Recipes/**/*.md. A * matches within a folder
+	// and ** matches any number of folders. A glob ending in / matches
+	// every note below that folder.
+	Paths []string `mapstructure:"paths" json:"paths,omitempty"`
+	// Match is RuleMatchAny when empty.
+	Match RuleMatch `mapstructure:"match" json:"match,omitempty"`
+}
+
+// DefaultRecipeRule is how recipes are recognized unless configured
+// otherwise.
+var DefaultRecipeRule = DetectionRule{Frontmatter: map[string]string{"filetype": "recipe"}}
+
+// ParseDetectionRule parses the compact form of a rule used on the command
+// line: comma separated conditions, each a frontmatter key=value pair, a
+// #tag or a path glob, such as
+//
+//	type=recipe,#recipe,Recipes/
+//
+// By default any condition has to hold. A leading "all:" requires every
+// one of them instead.
+func ParseDetectionRule(spec string) (DetectionRule, error) {
+	var rule DetectionRule
+	spec = strings.TrimSpace(spec)
+	if rest, ok := strings.CutPrefix(spec, "all:"); ok {
+		rule.Match = RuleMatchAll
+		spec = rest
+	}
+
+	for _, term := range strings.Split(spec, ",") {
+		term = strings.TrimSpace(term)
+		switch {
+		case term == "":
+		case strings.HasPrefix(term, "#"):
+			rule.Tags = append(rule.Tags, strings.TrimPrefix(term, "#"))
+		case strings.Contains(term, "="):
+			key, value, _ := strings.Cut(term, "=")
+			if rule.Frontmatter == nil {
+				rule.Frontmatter = make(map[string]string)
+			}
+			rule.Frontmatter[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		default:
+			rule.Paths = append(rule.Paths, term)
+		}
+	}
+
+	if err := rule.Validate(); err != nil {
+		return DetectionRule{}, err
+	}
+	return rule, nil
+}
+
+// IsZero reports whether the rule has no conditions.
+func (r DetectionRule) IsZero() bool {
+	return len(r.Frontmatter) == 0 && len(r.Tags) == 0 && len(r.Paths) == 0
+}
+
+// Validate reports conditions that can never be used for matching.
+func (r DetectionRule) Validate() error {
+	switch r.Match {
+	case "", RuleMatchAny, RuleMatchAll:
+	default:
+		return fmt.Errorf("invalid match %q, expected any or all", r.Match)
+	}
+	for key := range r.Frontmatter {
+		if strings.TrimSpace(key) == "" {
+			return fmt.Errorf("frontmatter condition without a key")
+		}
+	}
+	for _, tag := range r.Tags {
+		if strings.TrimSpace(strings.TrimPrefix(tag, "#")) == "" {
+			return fmt.Errorf("empty tag")
+		}
+	}
+	for _, glob := range r.Paths {
+		for _, part := range strings.Split(glob, "/") {
+			if _, err := path.Match(part, ""); err != nil {
+				return fmt.Errorf("invalid path glob %q: %w", glob, err)
+			}
+		}
+	}
+	return nil
+}
+
+// noteFacts is what a DetectionRule looks at.
+type noteFacts struct {
+	// rel is the path of the note relative to the base directory with
+	// forward slashes.
+	rel         string
+	frontmatter map[string]interface{}
+	tags        []string
+}
+
+func (r DetectionRule) matches(note noteFacts) bool {
+	var results []bool
+	for key, want := range r.Frontmatter {
+		results = append(results, frontmatterMatches(note.frontmatter, key, want))
+	}
+	for _, tag := range r.Tags {
+		results = append(results, hasTag(note.tags, tag))
+	}
+	for _, glob := range r.Paths {
+		results = append(results, matchGlob(glob, note.rel))
+	}
+
+	for _, ok := range results {
+		if ok && r.Match != RuleMatchAll {
+			return true
+		}
+		if !ok && r.Match == RuleMatchAll {
+			return false
+		}
+	}
+	return r.Match == RuleMatchAll && len(results) > 0
+}
+
+func frontmatterMatches(frontmatter map[string]interface{}, key, want string) bool {
+	value, ok := frontmatter[key]
+	if !ok {
+		for k, v := range frontmatter {
+			if strings.EqualFold(k, key) {
+				value, ok = v, true
+				break
+			}
+		}
+	}
+	if !ok || value == nil {
+		return false
+	}
+
+	values, ok := value.([]interface{})
+	if !ok {
+		values = []interface{}{value}
+	}
+	for _, v := range values {
+		s := strings.TrimSpace(fmt.Sprint(v))
+		if v != nil && (s == want || want == "*" && s != "") {
+			return true
+		}
+	}
+	return false
+}
+
+func hasTag(tags []string, want string) bool {
+	want = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(want), "#"))
+	for _, tag := range tags {
+		tag = strings.ToLower(tag)
+		if tag == want || strings.HasPrefix(tag, want+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob matches a slash separated path against glob ignoring case,
+// like the file systems notes usually live on.
+func matchGlob(glob, name string) bool {
+	glob = strings.ToLower(strings.TrimPrefix(glob, "/"))
+	if strings.HasSuffix(glob, "/") {
+		glob += "**"
+	}
+	return matchSegments(strings.Split(glob, "/"), strings.Split(strings.ToLower(name), "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := range len(name) + 1 {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// inlineTagPattern matches the tags Obsidian recognizes in the text of a
+// note: a # at the start of a word followed by letters, digits, _, - or /
+// and not only digits.
+var inlineTagPattern = regexp.MustCompile(`(?:^|\s)#([\p{L}\p{N}_/-]*[\p{L}_/-][\p{L}\p{N}_/-]*)`)
+
+// noteTags returns the tags of a note from its tags field and its text,
+// leaving out code.
+func noteTags(frontmatter map[string]interface{}, doc ast.Node, source []byte) []string {
+	var tags []string
+	if value, ok := frontmatter["tags"]; ok {
+		tags, _ = parseTags(value)
+	}
+
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.CodeSpan, *ast.CodeBlock, *ast.FencedCodeBlock, *ast.HTMLBlock:
+			return ast.WalkSkipChildren, nil
+		case *ast.Text:
+			for _, match := range inlineTagPattern.FindAllSubmatch(n.Value(source), -1) {
+				tags = append(tags, string(match[1]))
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	return tags
+}
+
+// Detection decides which notes are recipes and which notes creator links
+// may resolve to.
+type Detection struct {
+	// Recipe recognizes recipes. The zero rule means DefaultRecipeRule.
+	Recipe DetectionRule
+	// Creator recognizes creator notes. The zero rule accepts every note.
+	Creator DetectionRule
+}
+
+// Validate checks both rules.
+func (d Detection) Validate() error {
+	if err := d.Recipe.Validate(); err != nil {
+		return fmt.Errorf("invalid recipe rule: %w", err)
+	}
+	if err := d.Creator.Validate(); err != nil {
+		return fmt.Errorf("invalid creator rule: %w", err)
+	}
+	return nil
+}
+
+func (d Detection) recipeRule() DetectionRule {
+	if d.Recipe.IsZero() {
+		return DefaultRecipeRule
+	}
+	return d.Recipe
+}
+
+// key identifies the rules, so that cached detection results can be told
+// apart from results of other rules.
+func (d Detection) key() string {
+	b, _ := json.Marshal([]DetectionRule{d.recipeRule(), d.Creator})
+	return string(b)
+}
+
+// noteDetector applies a Detection to the notes below baseDir.
+type noteDetector struct {
+	baseDir string
+	Detection
+}
+
+func (d noteDetector) facts(notePath string, frontmatter map[string]interface{}, doc ast.Node, source []byte) noteFacts {
+	rel := notePath
+	if d.baseDir != "" {
+		if r, err := filepath.Rel(d.baseDir, notePath); err == nil {
+			rel = r
+		}
+	}
+	return noteFacts{
+		rel:         filepath.ToSlash(rel),
+		frontmatter: frontmatter,
+		tags:        noteTags(frontmatter, doc, source),
+	}
+}
+
+func (d noteDetector) isRecipe(note noteFacts) bool {
+	return d.recipeRule().matches(note)
+}
+
+func (d noteDetector) isCreator(note noteFacts) bool {
+	return d.Creator.IsZero() || d.Creator.matches(note)
+}
@@ -0,0 +1,131 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+)
+
+func TestDetectionRule_Matches(t *testing.T) {
+	notes := map[string]string{
+		"Recipes/Bread.md":   "# Bread\n",
+		"Typed.md":           "---\ntype: recipe\n---\n",
+		"Listed.md":          "---\ntype: [draft, recipe]\n---\n",
+		"Tagged.md":          "---\ntags: recipe\n---\n",
+		"Inline.md":          "Grandma's #recipe/dessert, see #42.\n",
+		"Code.md":            "Not a tag: `#recipe`\n\n```\n#recipe\n```\n",
+		"People/Jane.md":     "---\nchannel: janebakes\n---\n",
+		"Recipes/Old/Pie.md": "---\ntype: recipe\n---\n",
+	}
+
+	tests := []struct {
+		spec string
+		want []string
+	}{
+		{spec: "", want: nil},
+		{spec: "type=recipe", want: []string{"Listed.md", "Recipes/Old/Pie.md", "Typed.md"}},
+		{spec: "#recipe", want: []string{"Inline.md", "Tagged.md"}},
+		{spec: "#42", want: nil},
+		{spec: "Recipes/", want: []string{"Recipes/Bread.md", "Recipes/Old/Pie.md"}},
+		{spec: "recipes/*.md", want: []string{"Recipes/Bread.md"}},
+		{spec: "**/pie.md", want: []string{"Recipes/Old/Pie.md"}},
+		{spec: "channel=*", want: []string{"People/Jane.md"}},
+		{spec: "all:type=recipe,Recipes/", want: []string{"Recipes/Old/Pie.md"}},
+		{spec: "type=recipe,#recipe,Recipes/", want: []string{
+			"Inline.md", "Listed.md", "Recipes/Bread.md", "Recipes/Old/Pie.md", "Tagged.md", "Typed.md",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			rule, err := ParseDetectionRule(tt.spec)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var got []string
+			for rel, content := range notes {
+				source := []byte(content)
+				frontmatter, err := parseFrontmatter(source)
+				if err != nil {
+					t.Fatal(err)
+				}
+				facts := noteDetector{}.facts(rel, frontmatter, parseMarkdown(source), source)
+				if rule.matches(facts) {
+					got = append(got, rel)
+				}
+			}
+			sort.Strings(got)
+			if got, want := strings.Join(got, ","), strings.Join(tt.want, ","); got != want {
+				t.Errorf("Expected %s, got %s", want, got)
+			}
+		})
+	}
+}
+
+func TestParseDetectionRule_Errors(t *testing.T) {
+	for _, spec := range []string{"Recipes/[", "=recipe", "#"} {
+		if _, err := ParseDetectionRule(spec); err == nil {
+			t.Errorf("Expected error for %q", spec)
+		}
+	}
+}
+
+func TestGenerateMarkdown_Detection(t *testing.T) {
+	logger := testr.New(t)
+	baseDir := t.TempDir()
+	files := map[string]string{
+		"Recipes/Bread.md":   "---\npic: bread.jpg\ncreator: \"[[Sam]]\"\n---\n",
+		"Soup.md":            "---\npic: soup.jpg\ncreator: \"[[Sam]]\"\n---\n#recipe\n",
+		"Legacy.md":          "---\nfiletype: recipe\npic: legacy.jpg\ncreator: \"[[Sam]]\"\n---\n",
+		"People/Sam.md":      "---\npic: sam.jpg\n---\n",
+		"Old/People/Sam.md":  "---\npic: old-sam.jpg\n---\n",
+		"Notes/Shopping.md":  "Buy flour\n",
+		"Recipes/Drafts.txt": "not markdown\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(baseDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := GenerateOptions{
+		BaseDir: baseDir,
+		Strict:  true,
+		Detection: Detection{
+			Recipe:  DetectionRule{Tags: []string{"recipe"}, Paths: []string{"Recipes/"}},
+			Creator: DetectionRule{Paths: []string{"People/**"}},
+		},
+	}
+	// The cache is used on purpose: results detected with other rules must
+	// not be reused.
+	if err := GenerateMarkdownWithOptions(t.Context(), logger, GenerateOptions{BaseDir: baseDir}, NewTableMarkdownGenerator()); err != nil {
+		t.Fatalf("GenerateMarkdownWithOptions failed: %v", err)
+	}
+	if err := GenerateMarkdownWithOptions(t.Context(), logger, opts, NewTableMarkdownGenerator()); err != nil {
+		t.Fatalf("GenerateMarkdownWithOptions failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(baseDir, IndexFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"[[Bread]]", "[[Soup]]", "![[sam.jpg]] [[People/Sam]]"} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("Expected %s in the index. Got:\n%s", want, content)
+		}
+	}
+	for _, unwanted := range []string{"Legacy", "Shopping"} {
+		if strings.Contains(string(content), unwanted) {
+			t.Errorf("Did not expect %s in the index. Got:\n%s", unwanted, content)
+		}
+	}
+}
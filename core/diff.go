@@ -0,0 +1,254 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+// WriteDiff writes changes to w as a unified diff. Paths below baseDir are
+// shown relative to it with a/ and b/ prefixes, like git does, so the diff
+// can be applied from the vault.
+func WriteDiff(w io.Writer, baseDir string, changes []FileChange) error {
+	for _, change := range changes {
+		oldLabel, newLabel := diffLabel("a/", baseDir, change.Path), diffLabel("b/", baseDir, change.Path)
+		if change.Created {
+			oldLabel = "/dev/null"
+		}
+
+		var err error
+		if isBinary(change.Old) || isBinary(change.New) {
+			_, err = fmt.Fprintf(w, "Binary files %s and %s differ\n", oldLabel, newLabel)
+		} else {
+			_, err = io.WriteString(w, unifiedDiff(oldLabel, newLabel, string(change.Old), string(change.New)))
+		}
+		if err != nil {
+			return fmt.Errorf("error writing diff: %w", err)
+		}
+	}
+	return nil
+}
+
+func diffLabel(prefix, baseDir, path string) string {
+	rel, err := filepath.Rel(baseDir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	return prefix + filepath.ToSlash(rel)
+}
+
+func isBinary(content []byte) bool {
+	return bytes.IndexByte(content, 0) >= 0
+}
+
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+// unifiedDiff returns the unified diff turning oldText into newText, or
+// the empty string when they are equal.
+func unifiedDiff(oldLabel, newLabel, oldText, newText string) string {
+	ops := diffLines(splitLines(oldText), splitLines(newText))
+
+	var b strings.Builder
+	oldLine, newLine := 0, 0
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			oldLine++
+			newLine++
+			i++
+			continue
+		}
+
+		// A hunk starts diffContext lines before the change and runs until
+		// more than twice that many unchanged lines follow a change.
+		start := max(i-diffContext, 0)
+		for j := start; j < i; j++ {
+			oldLine--
+			newLine--
+		}
+		end, equal := i, 0
+		for j := i; j < len(ops) && equal <= 2*diffContext; j++ {
+			if ops[j].kind == ' ' {
+				equal++
+			} else {
+				equal = 0
+				end = j + 1
+			}
+		}
+		end = min(end+diffContext, len(ops))
+
+		oldCount, newCount := 0, 0
+		for _, op := range ops[start:end] {
+			if op.kind != '+' {
+				oldCount++
+			}
+			if op.kind != '-' {
+				newCount++
+			}
+		}
+
+		if b.Len() == 0 {
+			fmt.Fprintf(&b, "--- %s\n+++ %s\n", oldLabel, newLabel)
+		}
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(oldLine, oldCount), hunkRange(newLine, newCount))
+		for _, op := range ops[start:end] {
+			b.WriteByte(op.kind)
+			b.WriteString(op.line)
+			if !strings.HasSuffix(op.line, "\n") {
+				b.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+
+		oldLine += oldCount
+		newLine += newCount
+		i = end
+	}
+	return b.String()
+}
+
+// hunkRange formats the lines of a hunk starting after line as
+// start,count. Empty ranges name the line they follow.
+func hunkRange(line, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", line)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", line+1)
+	}
+	return fmt.Sprintf("%d,%d", line+1, count)
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines returns the shortest edit script turning a into b, found with
+// the linear space variant of Myers' algorithm: the middle snake of the
+// shortest path splits the inputs in two that are compared recursively, so
+// memory grows with the size of the inputs rather than with the number of
+// edits times that size.
+func diffLines(a, b []string) []diffOp {
+	d := differ{a: a, b: b}
+	d.compare(0, len(a), 0, len(b))
+	return d.ops
+}
+
+type differ struct {
+	a, b []string
+	ops  []diffOp
+	// vf and vb hold the furthest x reached on each diagonal by the forward
+	// and backward searches of middleSnake, reused between calls.
+	vf, vb []int
+}
+
+func (d *differ) compare(a0, a1, b0, b1 int) {
+	prefix := 0
+	for a0+prefix < a1 && b0+prefix < b1 && d.a[a0+prefix] == d.b[b0+prefix] {
+		d.ops = append(d.ops, diffOp{' ', d.a[a0+prefix]})
+		prefix++
+	}
+	a0, b0 = a0+prefix, b0+prefix
+
+	suffix := 0
+	for a0 < a1-suffix && b0 < b1-suffix && d.a[a1-suffix-1] == d.b[b1-suffix-1] {
+		suffix++
+	}
+	a1, b1 = a1-suffix, b1-suffix
+
+	switch {
+	case a0 == a1:
+		for _, line := range d.b[b0:b1] {
+			d.ops = append(d.ops, diffOp{'+', line})
+		}
+	case b0 == b1:
+		for _, line := range d.a[a0:a1] {
+			d.ops = append(d.ops, diffOp{'-', line})
+		}
+	default:
+		x, y, u, v := d.middleSnake(a0, a1, b0, b1)
+		d.compare(a0, x, b0, y)
+		for _, line := range d.a[x:u] {
+			d.ops = append(d.ops, diffOp{' ', line})
+		}
+		d.compare(u, a1, v, b1)
+	}
+
+	for _, line := range d.a[a1 : a1+suffix] {
+		d.ops = append(d.ops, diffOp{' ', line})
+	}
+}
+
+// middleSnake returns the start (x, y) and end (u, v) of the snake in the
+// middle of a shortest edit script turning a[a0:a1] into b[b0:b1], by
+// searching from both ends until the paths overlap. The backward search
+// runs on the reversed inputs, so its diagonal k is diagonal delta-k of
+// the forward one.
+func (d *differ) middleSnake(a0, a1, b0, b1 int) (x, y, u, v int) {
+	n, m := a1-a0, b1-b0
+	delta := n - m
+	odd := delta%2 != 0
+	limit := (n + m + 1) / 2
+	offset := limit + 1
+
+	size := 2*offset + 1
+	if len(d.vf) < size {
+		d.vf, d.vb = make([]int, size), make([]int, size)
+	}
+	vf, vb := d.vf[:size], d.vb[:size]
+	vf[offset+1], vb[offset+1] = 0, 0
+
+	for step := 0; step <= limit; step++ {
+		for k := -step; k <= step; k += 2 {
+			var fx int
+			if k == -step || (k != step && vf[offset+k-1] < vf[offset+k+1]) {
+				fx = vf[offset+k+1]
+			} else {
+				fx = vf[offset+k-1] + 1
+			}
+			sx, sy := fx, fx-k
+			fy := sy
+			for fx < n && fy < m && d.a[a0+fx] == d.b[b0+fy] {
+				fx++
+				fy++
+			}
+			vf[offset+k] = fx
+			if back := delta - k; odd && back >= -(step-1) && back <= step-1 && fx+vb[offset+back] >= n {
+				return a0 + sx, b0 + sy, a0 + fx, b0 + fy
+			}
+		}
+
+		for k := -step; k <= step; k += 2 {
+			var bx int
+			if k == -step || (k != step && vb[offset+k-1] < vb[offset+k+1]) {
+				bx = vb[offset+k+1]
+			} else {
+				bx = vb[offset+k-1] + 1
+			}
+			sx, sy := bx, bx-k
+			by := sy
+			for bx < n && by < m && d.a[a1-bx-1] == d.b[b1-by-1] {
+				bx++
+				by++
+			}
+			vb[offset+k] = bx
+			if fwd := delta - k; !odd && fwd >= -step && fwd <= step && bx+vf[offset+fwd] >= n {
+				return a1 - bx, b1 - by, a1 - sx, b1 - sy
+			}
+		}
+	}
+	panic("diff: no middle snake found")
+}
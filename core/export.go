@@ -0,0 +1,174 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// ExportSchemaVersion is incremented whenever a field of ExportRecipe or
+// ExportCreator is renamed, removed or changes meaning. Adding fields does
+// not change the version.
+const ExportSchemaVersion = 2
+
+// ExportDocument is the output of the json format.
+type ExportDocument struct {
+	SchemaVersion int            `json:"schema_version"`
+	Recipes       []ExportRecipe `json:"recipes"`
+}
+
+// ExportRecord is one line of the ndjson format.
+type ExportRecord struct {
+	SchemaVersion int `json:"schema_version"`
+	ExportRecipe
+}
+
+type ExportRecipe struct {
+	Title string `json:"title"`
+	// Name is the file name of the note that wikilinks use.
+	Name          string   `json:"name"`
+	Aliases       []string `json:"aliases"`
+	Slug          string   `json:"slug"`
+	SourcePath    string   `json:"source_path"`
+	ImageURL      string   `json:"image_url"`
+	IsRemoteImage bool     `json:"is_remote_image"`
+	// Creators are the creators that were found, in the order the recipe
+	// lists them.
+	Creators []ExportCreator `json:"creators"`
+	Tags     []string        `json:"tags"`
+	Cuisine  string          `json:"cuisine,omitempty"`
+	Course   string          `json:"course,omitempty"`
+	// PrepTime, CookTime and TotalTime are ISO 8601 durations.
+	PrepTime    string                 `json:"prep_time,omitempty"`
+	CookTime    string                 `json:"cook_time,omitempty"`
+	TotalTime   string                 `json:"total_time,omitempty"`
+	Servings    float64                `json:"servings,omitempty"`
+	Rating      float64                `json:"rating,omitempty"`
+	Source      string                 `json:"source,omitempty"`
+	DateAdded   string                 `json:"date_added,omitempty"`
+	Frontmatter map[string]interface{} `json:"frontmatter"`
+}
+
+type ExportCreator struct {
+	Name string `json:"name"`
+	// Alias is the display text of the recipe's link to the creator.
+	Alias         string                 `json:"alias,omitempty"`
+	SourcePath    string                 `json:"source_path"`
+	ImageURL      string                 `json:"image_url"`
+	IsRemoteImage bool                   `json:"is_remote_image"`
+	Frontmatter   map[string]interface{} `json:"frontmatter"`
+}
+
+func NewExportRecipe(recipe *RecipeInfo, creators map[string]*CreatorInfo) ExportRecipe {
+	exported := ExportRecipe{
+		Title:         recipe.Title,
+		Name:          recipe.Name,
+		Aliases:       nonNilStrings(recipe.Aliases),
+		Slug:          recipe.Slug,
+		SourcePath:    recipe.Path,
+		ImageURL:      recipe.ImageURL,
+		IsRemoteImage: recipe.IsRemoteImage,
+		Creators:      []ExportCreator{},
+		Tags:          nonNilStrings(recipe.Tags),
+		Cuisine:       recipe.Cuisine,
+		Course:        recipe.Course,
+		PrepTime:      isoDuration(recipe.PrepTime),
+		CookTime:      isoDuration(recipe.CookTime),
+		TotalTime:     isoDuration(recipe.TotalTime),
+		Servings:      recipe.Servings,
+		Rating:        recipe.Rating,
+		Source:        recipe.Source,
+		Frontmatter:   nonNilFrontmatter(recipe.Frontmatter),
+	}
+	if !recipe.DateAdded.IsZero() {
+		exported.DateAdded = recipe.DateAdded.Format("2006-01-02")
+	}
+
+	for _, ref := range recipeCreators(recipe, creators) {
+		if ref.unknown() {
+			continue
+		}
+		exported.Creators = append(exported.Creators, ExportCreator{
+			Name:          ref.Name,
+			Alias:         ref.Link.Alias,
+			SourcePath:    ref.Path,
+			ImageURL:      ref.ImageURL,
+			IsRemoteImage: ref.IsRemoteImage,
+			Frontmatter:   nonNilFrontmatter(ref.Frontmatter),
+		})
+	}
+
+	return exported
+}
+
+func nonNilFrontmatter(frontmatter map[string]interface{}) map[string]interface{} {
+	if frontmatter == nil {
+		return map[string]interface{}{}
+	}
+	return frontmatter
+}
+
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+type JSONGenerator struct{}
+
+func NewJSONGenerator() *JSONGenerator {
+	return &JSONGenerator{}
+}
+
+func (g *JSONGenerator) RawOutput() bool {
+	return true
+}
+
+func (g *JSONGenerator) Generate(_ context.Context, in GenerateInput) (string, error) {
+	logger, recipes, creators := in.Logger, in.Recipes, in.Creators
+	doc := ExportDocument{
+		SchemaVersion: ExportSchemaVersion,
+		Recipes:       make([]ExportRecipe, 0, len(recipes)),
+	}
+	for _, recipe := range recipes {
+		doc.Recipes = append(doc.Recipes, NewExportRecipe(recipe, creators))
+	}
+
+	content, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode recipes: %w", err)
+	}
+
+	logger.V(1).Info("Encoded recipes as JSON", "count", len(recipes))
+	return string(content) + "\n", nil
+}
+
+type NDJSONGenerator struct{}
+
+func NewNDJSONGenerator() *NDJSONGenerator {
+	return &NDJSONGenerator{}
+}
+
+func (g *NDJSONGenerator) RawOutput() bool {
+	return true
+}
+
+func (g *NDJSONGenerator) Generate(_ context.Context, in GenerateInput) (string, error) {
+	logger, recipes, creators := in.Logger, in.Recipes, in.Creators
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, recipe := range recipes {
+		record := ExportRecord{
+			SchemaVersion: ExportSchemaVersion,
+			ExportRecipe:  NewExportRecipe(recipe, creators),
+		}
+		if err := encoder.Encode(record); err != nil {
+			return "", fmt.Errorf("failed to encode recipe %s: %w", recipe.Title, err)
+		}
+	}
+
+	logger.V(1).Info("Encoded recipes as NDJSON", "count", len(recipes))
+	return buf.String(), nil
+}
@@ -0,0 +1,86 @@
+package core
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+)
+
+func TestJSONGenerator_Generate(t *testing.T) {
+	logger := testr.New(t)
+
+	recipes := []*RecipeInfo{
+		{
+			Path:     "Recipes/Pie.md",
+			Name:     "Pie",
+			Title:    "Pie",
+			Slug:     "pie",
+			ImageURL: "pie.jpg",
+			Creators: []Wikilink{{Target: "Jane", Alias: "JB"}},
+			Frontmatter: normalizeFrontmatter(map[string]interface{}{
+				"filetype": "recipe",
+				"times":    map[interface{}]interface{}{"prep": 10},
+			}),
+		},
+		{Title: "Orphan", Slug: "orphan", Creators: []Wikilink{{Target: "Nobody"}}},
+	}
+	creators := map[string]*CreatorInfo{
+		"Jane": {Path: "Jane.md", Name: "Jane", ImageURL: "https://example.com/jane.jpg", IsRemoteImage: true},
+	}
+
+	result, err := NewJSONGenerator().Generate(t.Context(), GenerateInput{Logger: logger, Recipes: recipes, Creators: creators})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var doc ExportDocument
+	if err := json.Unmarshal([]byte(result), &doc); err != nil {
+		t.Fatalf("Output is not a valid ExportDocument: %v\n%s", err, result)
+	}
+
+	if doc.SchemaVersion != ExportSchemaVersion {
+		t.Errorf("Expected schema version %d, got %d", ExportSchemaVersion, doc.SchemaVersion)
+	}
+	if len(doc.Recipes) != 2 || doc.Recipes[0].Title != "Pie" || doc.Recipes[1].Title != "Orphan" {
+		t.Fatalf("Expected recipes in input order, got %+v", doc.Recipes)
+	}
+	if creators := doc.Recipes[1].Creators; creators == nil || len(creators) != 0 {
+		t.Errorf("Expected empty creators for unknown creator, got %#v", creators)
+	}
+
+	pie := doc.Recipes[0]
+	if pie.SourcePath != "Recipes/Pie.md" || len(pie.Creators) != 1 ||
+		pie.Creators[0].SourcePath != "Jane.md" || pie.Creators[0].Alias != "JB" {
+		t.Errorf("Unexpected recipe %+v", pie)
+	}
+	times, ok := pie.Frontmatter["times"].(map[string]interface{})
+	if !ok || times["prep"] != float64(10) {
+		t.Errorf("Expected nested frontmatter to survive encoding, got %#v", pie.Frontmatter)
+	}
+}
+
+func TestNDJSONGenerator_Generate(t *testing.T) {
+	logger := testr.New(t)
+
+	recipes := []*RecipeInfo{{Title: "B"}, {Title: "a"}}
+	result, err := NewNDJSONGenerator().Generate(t.Context(), GenerateInput{Logger: logger, Recipes: recipes})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(result, "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected one line per recipe, got %q", result)
+	}
+	for i, want := range []string{"B", "a"} {
+		var record ExportRecord
+		if err := json.Unmarshal([]byte(lines[i]), &record); err != nil {
+			t.Fatalf("Line %d is not a valid ExportRecord: %v", i, err)
+		}
+		if record.SchemaVersion != ExportSchemaVersion || record.Title != want {
+			t.Errorf("Line %d: expected %q with schema version, got %+v", i, want, record)
+		}
+	}
+}
@@ -1,41 +1,64 @@
 package core
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/go-logr/logr"
 )
 
 func FindMarkdownFiles(logger logr.Logger, baseDir string) ([]string, error) {
+	return FindMarkdownFilesWithOptions(logger, baseDir, WalkOptions{})
+}
+
+// FindMarkdownFilesWithOptions returns the markdown files below baseDir
+// that are not left out by opts, the IgnoreFileName file in baseDir or the
+// directories that never hold notes.
+func FindMarkdownFilesWithOptions(logger logr.Logger, baseDir string, opts WalkOptions) ([]string, error) {
+	return findMarkdownFiles(logger, newVault(baseDir, nil), opts)
+}
+
+func findMarkdownFiles(logger logr.Logger, v vault, opts WalkOptions) ([]string, error) {
+	filter, err := newWalkFilter(logger, v, opts)
+	if err != nil {
+		return nil, err
+	}
+
 	var files []string
 	var skippedCount int
 
-	err := filepath.Walk(baseDir, func(path string, info os.FileInfo, err error) error {
+	err = v.walk(func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
+			if path == v.path(".") {
+				return err
+			}
 			logger.Error(err, "Error accessing path", "path", path)
 			return nil
 		}
 
-		logger.V(2).Info("Encountered file", "path", path, "isDir", info.IsDir())
+		logger.V(2).Info("Encountered file", "path", path, "isDir", d.IsDir())
 
-		if info.IsDir() {
-			if strings.Contains(strings.ToLower(path), ".git") ||
-				strings.Contains(strings.ToLower(path), ".trash") {
-				logger.V(2).
-					Info("Skipping directory", "path", path, "reason", "contains .git or .trash")
-				return filepath.SkipDir
+		if d.IsDir() {
+			if reason := filter.skip(path, true); reason != "" {
+				logger.V(2).Info("Skipping directory", "path", path, "reason", reason)
+				return fs.SkipDir
 			}
 			return nil
 		}
 
-		if strings.HasSuffix(strings.ToLower(info.Name()), ".md") {
-			if strings.HasPrefix(info.Name(), ".#") {
+		if strings.HasSuffix(strings.ToLower(d.Name()), ".md") {
+			if isTemporaryFile(d.Name()) {
 				logger.V(2).Info("Skipping temporary file", "path", path)
 				skippedCount++
+			} else if reason := filter.skip(path, false); reason != "" {
+				logger.V(2).Info("Skipping markdown file", "path", path, "reason", reason)
+				skippedCount++
 			} else {
 				logger.V(2).Info("Including markdown file", "path", path)
 				files = append(files, path)
@@ -56,6 +79,27 @@ func FindMarkdownFiles(logger logr.Logger, baseDir string) ([]string, error) {
 	return files, err
 }
 
+// skippedDirs are the names of directories that never hold notes: version
+// control, the trash and settings of Obsidian and the cache.
+var skippedDirs = map[string]bool{
+	".git":       true,
+	".trash":     true,
+	".obsidian":  true,
+	CacheDirName: true,
+}
+
+func isSkippedDir(path string) bool {
+	return skippedDirs[strings.ToLower(filepath.Base(path))]
+}
+
+func isTemporaryFile(name string) bool {
+	return strings.HasPrefix(name, ".#")
+}
+
+func isMarkdownFile(name string) bool {
+	return strings.HasSuffix(strings.ToLower(name), ".md") && !isTemporaryFile(name)
+}
+
 func ReadFile(logger logr.Logger, path string) ([]byte, error) {
 	content, err := os.ReadFile(path)
 	if err != nil {
@@ -66,8 +110,69 @@ func ReadFile(logger logr.Logger, path string) ([]byte, error) {
 	return content, nil
 }
 
+// Writer stores the files the tool generates. Paths are OS paths, as given
+// by the output options.
+type Writer interface {
+	// ReadFile returns the content of the file at path, or an error
+	// matching fs.ErrNotExist when there is none.
+	ReadFile(path string) ([]byte, error)
+	// WriteFile replaces the file at path, creating its directory.
+	WriteFile(path string, content []byte) error
+}
+
+// OSWriter writes files to disk.
+type OSWriter struct{}
+
+func (OSWriter) ReadFile(path string) ([]byte, error) {
+	return os.ReadFile(path)
+}
+
+func (OSWriter) WriteFile(path string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0o644)
+}
+
+// MemWriter keeps files in memory instead of writing them, for tests and
+// previews. Files can be filled in beforehand to stand for existing output.
+type MemWriter struct {
+	mu    sync.Mutex
+	Files map[string][]byte
+}
+
+func NewMemWriter() *MemWriter {
+	return &MemWriter{Files: make(map[string][]byte)}
+}
+
+func (w *MemWriter) ReadFile(path string) ([]byte, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	content, ok := w.Files[filepath.Clean(path)]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: path, Err: fs.ErrNotExist}
+	}
+	return bytes.Clone(content), nil
+}
+
+func (w *MemWriter) WriteFile(path string, content []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.Files == nil {
+		w.Files = make(map[string][]byte)
+	}
+	w.Files[filepath.Clean(path)] = bytes.Clone(content)
+	return nil
+}
+
 func WriteFile(logger logr.Logger, path string, content []byte) error {
-	existingContent, err := ReadFile(logger, path)
+	return writeFile(logger, OSWriter{}, path, content)
+}
+
+// writeFile writes content to path through w unless the file already has
+// exactly this content.
+func writeFile(logger logr.Logger, w Writer, path string, content []byte) error {
+	existingContent, err := w.ReadFile(path)
 	if err == nil {
 		existingDigest := calculateDigest(existingContent)
 		newDigest := calculateDigest(content)
@@ -78,7 +183,7 @@ func WriteFile(logger logr.Logger, path string, content []byte) error {
 		}
 	}
 
-	err = os.WriteFile(path, content, 0o644)
+	err = w.WriteFile(path, content)
 	if err != nil {
 		logger.Error(err, "Failed to write file", "path", path)
 	} else {
@@ -0,0 +1,282 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RecipeFilter reports whether a recipe belongs in an index.
+type RecipeFilter func(recipe *RecipeInfo) bool
+
+// ParseFilter parses a filter expression such as
+//
+//	tags contains "vegan" and total_time <= 30m and creator != "Jane"
+//
+// Comparisons are combined with and, or, not and parentheses; a comma is
+// another way to write and. Fields are the recipe metadata fields, title,
+// name, creator and any other frontmatter field. Text is compared
+// case-insensitively, and times, numbers and dates by value. A list field
+// matches when any of its items does. The title field also matches the
+// aliases of a recipe and name matches its file name. An empty filter
+// matches every recipe.
+//
+// Syntax errors are returned as a *FilterSyntaxError.
+func ParseFilter(expr string) (RecipeFilter, error) {
+	if strings.TrimSpace(expr) == "" {
+		return func(*RecipeInfo) bool { return true }, nil
+	}
+
+	node, err := parseFilterExpr(expr)
+	if err != nil {
+		return nil, err
+	}
+	return node.match, nil
+}
+
+type filterNode interface {
+	match(recipe *RecipeInfo) bool
+}
+
+type andNode struct{ left, right filterNode }
+
+func (n andNode) match(recipe *RecipeInfo) bool {
+	return n.left.match(recipe) && n.right.match(recipe)
+}
+
+type orNode struct{ left, right filterNode }
+
+func (n orNode) match(recipe *RecipeInfo) bool {
+	return n.left.match(recipe) || n.right.match(recipe)
+}
+
+type notNode struct{ operand filterNode }
+
+func (n notNode) match(recipe *RecipeInfo) bool {
+	return !n.operand.match(recipe)
+}
+
+// fieldKind decides how the values of a field are compared.
+type fieldKind int
+
+const (
+	kindText fieldKind = iota
+	kindNumber
+	kindDuration
+	kindDate
+)
+
+var fieldKinds = map[string]fieldKind{
+	"prep_time":  kindDuration,
+	"cook_time":  kindDuration,
+	"total_time": kindDuration,
+	"servings":   kindNumber,
+	"rating":     kindNumber,
+	"date_added": kindDate,
+}
+
+// fieldValue holds the values of a field of a recipe. The values of a list
+// field are matched one by one, while contains looks for a substring in
+// the values of other fields.
+type fieldValue struct {
+	items []interface{}
+	list  bool
+}
+
+func recipeField(recipe *RecipeInfo, key string) fieldValue {
+	switch key {
+	case "title":
+		items := []interface{}{recipe.Title}
+		for _, alias := range recipe.Aliases {
+			items = append(items, alias)
+		}
+		return fieldValue{items: items}
+	case "name":
+		return textField(recipe.Name)
+	case "creator":
+		return listField(creatorNames(recipe))
+	case "tags":
+		return listField(recipe.Tags)
+	case "cuisine":
+		return textField(recipe.Cuisine)
+	case "course":
+		return textField(recipe.Course)
+	case "source":
+		return textField(recipe.Source)
+	case "prep_time":
+		return durationField(recipe.PrepTime)
+	case "cook_time":
+		return durationField(recipe.CookTime)
+	case "total_time":
+		return durationField(recipe.TotalTime)
+	case "servings":
+		if recipe.Servings == 0 {
+			return fieldValue{}
+		}
+		return fieldValue{items: []interface{}{recipe.Servings}}
+	case "rating":
+		if recipe.Rating == 0 && recipe.Frontmatter["rating"] == nil {
+			return fieldValue{}
+		}
+		return fieldValue{items: []interface{}{recipe.Rating}}
+	case "date_added":
+		if recipe.DateAdded.IsZero() {
+			return fieldValue{}
+		}
+		return fieldValue{items: []interface{}{recipe.DateAdded}}
+	}
+
+	switch v := recipe.Frontmatter[key].(type) {
+	case nil:
+		return fieldValue{}
+	case []interface{}:
+		var items []interface{}
+		for _, item := range v {
+			if item != nil {
+				items = append(items, fmt.Sprint(item))
+			}
+		}
+		return fieldValue{items: items, list: true}
+	default:
+		return fieldValue{items: []interface{}{fmt.Sprint(v)}}
+	}
+}
+
+func textField(s string) fieldValue {
+	if s == "" {
+		return fieldValue{}
+	}
+	return fieldValue{items: []interface{}{s}}
+}
+
+func listField(values []string) fieldValue {
+	items := make([]interface{}, len(values))
+	for i, v := range values {
+		items[i] = v
+	}
+	return fieldValue{items: items, list: true}
+}
+
+func durationField(d time.Duration) fieldValue {
+	if d == 0 {
+		return fieldValue{}
+	}
+	return fieldValue{items: []interface{}{d}}
+}
+
+// comparisonNode compares a field against the values written in the
+// filter, which are converted to the kind of the field when parsing.
+type comparisonNode struct {
+	field    string
+	operator string
+	values   []interface{}
+}
+
+func newComparison(p *filterParser, field, operator string, literals []filterLiteral) (filterNode, error) {
+	kind := fieldKinds[field]
+	if operator == "contains" && kind != kindText {
+		return nil, p.errorAt(literals[0].tok, "contains only works with text fields, %s is not one", field)
+	}
+
+	node := comparisonNode{field: field, operator: operator}
+	for _, literal := range literals {
+		value, err := convertLiteral(kind, literal.text)
+		if err != nil {
+			return nil, p.errorAt(literal.tok, "invalid value %q for %s: %v", literal.text, field, err)
+		}
+		node.values = append(node.values, value)
+	}
+
+	if operator == "!=" {
+		node.operator = "=="
+		return notNode{node}, nil
+	}
+	return node, nil
+}
+
+func convertLiteral(kind fieldKind, text string) (interface{}, error) {
+	switch kind {
+	case kindNumber:
+		n, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, errNotNumber
+		}
+		return n, nil
+	case kindDuration:
+		return parseDuration(text)
+	case kindDate:
+		return parseDate(text)
+	default:
+		return text, nil
+	}
+}
+
+func (n comparisonNode) match(recipe *RecipeInfo) bool {
+	field := recipeField(recipe, n.field)
+	for _, item := range field.items {
+		for _, value := range n.values {
+			if n.matchItem(item, value, field.list) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (n comparisonNode) matchItem(item, value interface{}, list bool) bool {
+	if n.operator == "contains" {
+		if list {
+			return compareValues(item, value) == 0
+		}
+		return strings.Contains(strings.ToLower(fmt.Sprint(item)), strings.ToLower(fmt.Sprint(value)))
+	}
+
+	c := compareValues(item, value)
+	switch n.operator {
+	case "==", "in":
+		return c == 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	default:
+		return false
+	}
+}
+
+// compareValues orders two values of the same kind. Text that looks like a
+// number on both sides is compared as numbers, other text ignoring case.
+func compareValues(a, b interface{}) int {
+	switch a := a.(type) {
+	case float64:
+		return compareOrdered(a, b.(float64))
+	case time.Duration:
+		return compareOrdered(a, b.(time.Duration))
+	case time.Time:
+		return a.Compare(b.(time.Time))
+	}
+
+	as, bs := fmt.Sprint(a), fmt.Sprint(b)
+	an, aErr := strconv.ParseFloat(as, 64)
+	bn, bErr := strconv.ParseFloat(bs, 64)
+	if aErr == nil && bErr == nil {
+		return compareOrdered(an, bn)
+	}
+	return strings.Compare(strings.ToLower(as), strings.ToLower(bs))
+}
+
+func compareOrdered[T float64 | time.Duration](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,365 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// FilterSyntaxError reports where a filter expression could not be parsed.
+type FilterSyntaxError struct {
+	Expr string
+	// Column is the 1-based position of the offending character, counted
+	// in characters rather than bytes.
+	Column  int
+	Message string
+}
+
+func (e *FilterSyntaxError) Error() string {
+	return fmt.Sprintf("column %d: %s\n  %s\n  %s^",
+		e.Column, e.Message, e.Expr, strings.Repeat(" ", e.Column-1))
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenOperator
+	tokenLParen
+	tokenRParen
+	tokenLBracket
+	tokenRBracket
+	tokenComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	// pos is the byte offset of the token in the expression.
+	pos int
+}
+
+// is reports whether t is the keyword or operator s.
+func (t token) is(s string) bool {
+	switch t.kind {
+	case tokenWord:
+		return strings.EqualFold(t.text, s)
+	case tokenOperator:
+		return t.text == s
+	default:
+		return false
+	}
+}
+
+func (t token) describe() string {
+	if t.kind == tokenEOF {
+		return "end of filter"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+var filterKeywords = []string{"and", "or", "not", "contains", "in"}
+
+func isKeyword(t token) bool {
+	for _, keyword := range filterKeywords {
+		if t.is(keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// wordBreaks are the characters that end a bare word.
+const wordBreaks = `()[],!=<>&|`
+
+func lexFilter(expr string) ([]token, error) {
+	var tokens []token
+	for pos := 0; pos < len(expr); {
+		r, size := utf8.DecodeRuneInString(expr[pos:])
+		switch {
+		case unicode.IsSpace(r):
+			pos += size
+		case r == '(':
+			tokens = append(tokens, token{tokenLParen, "(", pos})
+			pos++
+		case r == ')':
+			tokens = append(tokens, token{tokenRParen, ")", pos})
+			pos++
+		case r == '[':
+			tokens = append(tokens, token{tokenLBracket, "[", pos})
+			pos++
+		case r == ']':
+			tokens = append(tokens, token{tokenRBracket, "]", pos})
+			pos++
+		case r == ',':
+			tokens = append(tokens, token{tokenComma, ",", pos})
+			pos++
+		case r == '"' || r == '\'':
+			text, end, err := lexString(expr, pos)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{tokenString, text, pos})
+			pos = end
+		case strings.ContainsRune("!=<>&|", r):
+			op := lexOperator(expr[pos:])
+			if op == "" {
+				return nil, syntaxError(expr, pos, "unexpected %q", string(r))
+			}
+			tokens = append(tokens, token{tokenOperator, op, pos})
+			pos += len(op)
+		default:
+			end := pos
+			for end < len(expr) {
+				r, size := utf8.DecodeRuneInString(expr[end:])
+				if unicode.IsSpace(r) || strings.ContainsRune(wordBreaks, r) {
+					break
+				}
+				end += size
+			}
+			tokens = append(tokens, token{tokenWord, expr[pos:end], pos})
+			pos = end
+		}
+	}
+	return append(tokens, token{tokenEOF, "", len(expr)}), nil
+}
+
+func lexOperator(s string) string {
+	for _, op := range []string{"==", "!=", "<=", ">=", "&&", "||", "=", "<", ">", "!"} {
+		if strings.HasPrefix(s, op) {
+			return op
+		}
+	}
+	return ""
+}
+
+// lexString reads the quoted string starting at pos, in which the quote and
+// backslash can be escaped with a backslash.
+func lexString(expr string, pos int) (string, int, error) {
+	quote := expr[pos]
+	var b strings.Builder
+	for i := pos + 1; i < len(expr); i++ {
+		switch c := expr[i]; {
+		case c == '\\' && i+1 < len(expr):
+			i++
+			b.WriteByte(expr[i])
+		case c == quote:
+			return b.String(), i + 1, nil
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, syntaxError(expr, pos, "unterminated string")
+}
+
+func syntaxError(expr string, pos int, format string, args ...interface{}) *FilterSyntaxError {
+	return &FilterSyntaxError{
+		Expr:    expr,
+		Column:  utf8.RuneCountInString(expr[:pos]) + 1,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// filterParser is a recursive descent parser for the grammar
+//
+//	or         = and { ("or" | "||") and }
+//	and        = unary { ("and" | "&&" | ",") unary }
+//	unary      = ("not" | "!") unary | "(" or ")" | comparison
+//	comparison = field ( operator value | "in" list )
+//	value      = string | word { word }
+//	list       = "[" [ value { "," value } ] "]"
+type filterParser struct {
+	expr   string
+	tokens []token
+	pos    int
+}
+
+func parseFilterExpr(expr string) (filterNode, error) {
+	tokens, err := lexFilter(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &filterParser{expr: expr, tokens: tokens}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if next := p.peek(); next.kind != tokenEOF {
+		return nil, p.errorAt(next, "unexpected %s, expected and, or or the end of the filter", next.describe())
+	}
+	return node, nil
+}
+
+func (p *filterParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *filterParser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *filterParser) errorAt(t token, format string, args ...interface{}) error {
+	return syntaxError(p.expr, t.pos, format, args...)
+}
+
+func (p *filterParser) parseOr() (filterNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().is("or") || p.peek().is("||") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (filterNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().is("and") || p.peek().is("&&") || p.peek().kind == tokenComma {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseUnary() (filterNode, error) {
+	t := p.peek()
+	switch {
+	case t.is("not") || t.is("!"):
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand}, nil
+	case t.kind == tokenLParen:
+		p.next()
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, p.errorAt(closing, "expected \")\" to close the \"(\" at column %d, found %s",
+				syntaxError(p.expr, t.pos, "").Column, closing.describe())
+		}
+		return node, nil
+	default:
+		return p.parseComparison()
+	}
+}
+
+func (p *filterParser) parseComparison() (filterNode, error) {
+	field := p.next()
+	if field.kind != tokenWord || isKeyword(field) {
+		return nil, p.errorAt(field, "expected a field name, found %s", field.describe())
+	}
+	name := strings.ToLower(field.text)
+
+	op := p.peek()
+	switch {
+	case op.is("in"):
+		p.next()
+		values, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		return newComparison(p, name, "in", values)
+	case op.is("contains"):
+		p.next()
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		return newComparison(p, name, "contains", []filterLiteral{value})
+	case op.kind == tokenOperator && comparisonOperators[op.text]:
+		p.next()
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		operator := op.text
+		if operator == "=" {
+			operator = "=="
+		}
+		return newComparison(p, name, operator, []filterLiteral{value})
+	default:
+		return nil, p.errorAt(op, "expected an operator such as == or contains after %q, found %s",
+			field.text, op.describe())
+	}
+}
+
+var comparisonOperators = map[string]bool{"=": true, "==": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true}
+
+// filterLiteral is a value written in a filter together with where it was
+// written, for error messages.
+type filterLiteral struct {
+	text string
+	tok  token
+}
+
+// parseValue reads a quoted string or a run of bare words, so that
+// creator == Jane Baker compares against "Jane Baker".
+func (p *filterParser) parseValue() (filterLiteral, error) {
+	first := p.peek()
+	switch {
+	case first.kind == tokenString:
+		p.next()
+		return filterLiteral{first.text, first}, nil
+	case first.kind == tokenWord && !isKeyword(first):
+		words := []string{p.next().text}
+		for p.peek().kind == tokenWord && !isKeyword(p.peek()) {
+			words = append(words, p.next().text)
+		}
+		return filterLiteral{strings.Join(words, " "), first}, nil
+	default:
+		return filterLiteral{}, p.errorAt(first, "expected a value, found %s", first.describe())
+	}
+}
+
+func (p *filterParser) parseList() ([]filterLiteral, error) {
+	open := p.next()
+	if open.kind != tokenLBracket {
+		return nil, p.errorAt(open, "expected a list such as [a, b] after in, found %s", open.describe())
+	}
+
+	var values []filterLiteral
+	if p.peek().kind == tokenRBracket {
+		p.next()
+		return values, nil
+	}
+	for {
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+
+		switch t := p.next(); t.kind {
+		case tokenComma:
+		case tokenRBracket:
+			return values, nil
+		default:
+			return nil, p.errorAt(t, "expected \",\" or \"]\" in list, found %s", t.describe())
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package core
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseFilter(t *testing.T) {
+	quickVegan := &RecipeInfo{
+		Title:    "Chickpea Curry",
+		Aliases:  []string{"Chana Masala"},
+		Creators: []Wikilink{{Target: "Sam"}},
+		RecipeMetadata: RecipeMetadata{
+			Tags:      []string{"vegan", "Weeknight"},
+			Cuisine:   "Indian",
+			Course:    "dinner",
+			TotalTime: 25 * time.Minute,
+			Rating:    4.5,
+			DateAdded: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		},
+		Frontmatter: map[string]interface{}{"difficulty": "easy", "rating": 4.5},
+	}
+	slowRoast := &RecipeInfo{
+		Title:    "Sunday Roast",
+		Creators: []Wikilink{{Target: "Jane"}, {Target: "Sam"}},
+		RecipeMetadata: RecipeMetadata{
+			Tags:      []string{"meat"},
+			Course:    "dinner",
+			TotalTime: 3 * time.Hour,
+			Servings:  6,
+		},
+	}
+
+	tests := []struct {
+		expr string
+		want []bool
+	}{
+		{expr: "", want: []bool{true, true}},
+		{expr: `tags contains "vegan" and total_time <= 30m and creator != "Jane"`, want: []bool{true, false}},
+		{expr: "course=dinner,cuisine=indian", want: []bool{true, false}},
+		{expr: "creator = sam", want: []bool{true, true}},
+		{expr: "creator == Jane or rating >= 4", want: []bool{true, true}},
+		{expr: "not (creator == Jane)", want: []bool{true, false}},
+		{expr: "!(tags contains vegan) && servings > 4", want: []bool{false, true}},
+		{expr: "total_time > PT1H", want: []bool{false, true}},
+		{expr: `total_time < "1 hour 30 min"`, want: []bool{true, false}},
+		{expr: "title contains masala", want: []bool{true, false}},
+		{expr: "title == Sunday Roast", want: []bool{false, true}},
+		{expr: "cuisine in [italian, Indian]", want: []bool{true, false}},
+		{expr: "date_added >= 2024-01-01", want: []bool{true, false}},
+		{expr: "difficulty == easy", want: []bool{true, false}},
+		{expr: "rating < 3", want: []bool{false, false}},
+		{expr: "cuisine != indian", want: []bool{false, true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			filter, err := ParseFilter(tt.expr)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			for i, recipe := range []*RecipeInfo{quickVegan, slowRoast} {
+				if got := filter(recipe); got != tt.want[i] {
+					t.Errorf("Expected %t for %s, got %t", tt.want[i], recipe.Title, got)
+				}
+			}
+		})
+	}
+}
+
+func TestParseFilter_SyntaxErrors(t *testing.T) {
+	tests := []struct {
+		expr    string
+		column  int
+		message string
+	}{
+		{expr: "tags", column: 5, message: `expected an operator such as == or contains after "tags", found end of filter`},
+		{expr: "total_time <= soon", column: 15, message: `invalid value "soon" for total_time: ` + errNotDuration.Error()},
+		{expr: "tags contains", column: 14, message: "expected a value, found end of filter"},
+		{expr: `title == "pie`, column: 10, message: "unterminated string"},
+		{expr: "(course == dinner", column: 18, message: `expected ")" to close the "(" at column 1, found end of filter`},
+		{expr: "course == dinner dessert)", column: 25, message: `unexpected ")", expected and, or or the end of the filter`},
+		{expr: "rating contains 4", column: 17, message: "contains only works with text fields, rating is not one"},
+		{expr: "cuisine in italian", column: 12, message: `expected a list such as [a, b] after in, found "italian"`},
+		{expr: "é == x and", column: 11, message: `expected a field name, found end of filter`},
+		{expr: "course & dinner", column: 8, message: `unexpected "&"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := ParseFilter(tt.expr)
+			var syntaxErr *FilterSyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("Expected a *FilterSyntaxError, got %v", err)
+			}
+			if syntaxErr.Column != tt.column || syntaxErr.Message != tt.message {
+				t.Errorf("Expected column %d: %s\ngot column %d: %s",
+					tt.column, tt.message, syntaxErr.Column, syntaxErr.Message)
+			}
+		})
+	}
+}
+
+func TestFilterSyntaxError_Error(t *testing.T) {
+	_, err := ParseFilter("servings > many")
+	want := "column 12: invalid value \"many\" for servings: expected a number\n" +
+		"  servings > many\n" +
+		"             ^"
+	if err == nil || err.Error() != want {
+		t.Errorf("Unexpected error.\nGot:\n%v\nWant:\n%s", err, want)
+	}
+}
@@ -0,0 +1,206 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// FormatOption describes a setting a format accepts.
+type FormatOption struct {
+	Name        string
+	Description string
+}
+
+// Format is an output format that generators are made for by name. The
+// built-in formats are registered by this package; other packages can add
+// their own with RegisterFormat.
+type Format struct {
+	Name        string
+	Description string
+	// Options are the settings New accepts. Settings that are not listed
+	// are rejected before New is called.
+	Options []FormatOption
+	// New returns a generator configured with settings, which are keyed
+	// by option name and only hold the options that were set.
+	New func(settings map[string]string) (MarkdownGenerator, error)
+}
+
+var formats = struct {
+	sync.RWMutex
+	byName map[string]Format
+}{byName: make(map[string]Format)}
+
+// RegisterFormat makes f available by its name. Names are matched case
+// insensitively and cannot be registered twice.
+func RegisterFormat(f Format) error {
+	name := strings.ToLower(strings.TrimSpace(f.Name))
+	switch {
+	case name == "":
+		return fmt.Errorf("format has no name")
+	case name == "list" || name == "html":
+		return fmt.Errorf("format name %q is reserved", name)
+	case f.New == nil:
+		return fmt.Errorf("format %q has no New function", name)
+	}
+
+	formats.Lock()
+	defer formats.Unlock()
+	if _, ok := formats.byName[name]; ok {
+		return fmt.Errorf("format %q is already registered", name)
+	}
+	f.Name = name
+	formats.byName[name] = f
+	return nil
+}
+
+// LookupFormat returns the format registered as name.
+func LookupFormat(name string) (Format, bool) {
+	formats.RLock()
+	defer formats.RUnlock()
+	f, ok := formats.byName[strings.ToLower(strings.TrimSpace(name))]
+	return f, ok
+}
+
+// Formats returns every registered format ordered by name.
+func Formats() []Format {
+	formats.RLock()
+	defer formats.RUnlock()
+	list := make([]Format, 0, len(formats.byName))
+	for _, f := range formats.byName {
+		list = append(list, f)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// NewFormatGenerator returns a generator of the format registered as name,
+// configured with settings.
+func NewFormatGenerator(name string, settings map[string]string) (MarkdownGenerator, error) {
+	f, ok := LookupFormat(name)
+	if !ok {
+		var names []string
+		for _, f := range Formats() {
+			names = append(names, f.Name)
+		}
+		return nil, fmt.Errorf("invalid format specified: %s, expected one of %s", name, strings.Join(names, ", "))
+	}
+
+	for key := range settings {
+		if !f.hasOption(key) {
+			if len(f.Options) == 0 {
+				return nil, fmt.Errorf("the %s format has no options, got %s", f.Name, key)
+			}
+			return nil, fmt.Errorf("the %s format has no %s option, expected one of %s",
+				f.Name, key, strings.Join(f.optionNames(), ", "))
+		}
+	}
+
+	generator, err := f.New(settings)
+	if err != nil {
+		return nil, fmt.Errorf("the %s format: %w", f.Name, err)
+	}
+	return generator, nil
+}
+
+func (f Format) hasOption(name string) bool {
+	for _, option := range f.Options {
+		if option.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (f Format) optionNames() []string {
+	names := make([]string, len(f.Options))
+	for i, option := range f.Options {
+		names[i] = option.Name
+	}
+	return names
+}
+
+// layoutOptions are the options of the sections and table formats.
+var layoutOptions = []FormatOption{
+	{Name: "columns", Description: "extra metadata columns, e.g. cuisine,total_time,rating"},
+	{Name: "group_by", Description: "group by creator, tag, cuisine, course, letter or year-added"},
+}
+
+func parseLayout(settings map[string]string) (Layout, error) {
+	var layout Layout
+	var err error
+	if layout.Columns, err = ParseColumns(settings["columns"]); err != nil {
+		return Layout{}, err
+	}
+	if layout.GroupBy, err = ParseGroupBy(settings["group_by"]); err != nil {
+		return Layout{}, err
+	}
+	return layout, nil
+}
+
+// layoutSettings returns the settings of the sections and table formats
+// that make up layout.
+func layoutSettings(layout Layout) map[string]string {
+	settings := make(map[string]string)
+	if len(layout.Columns) > 0 {
+		columns := make([]string, len(layout.Columns))
+		for i, column := range layout.Columns {
+			columns[i] = string(column)
+		}
+		settings["columns"] = strings.Join(columns, ",")
+	}
+	if layout.GroupBy != GroupByNone {
+		settings["group_by"] = string(layout.GroupBy)
+	}
+	return settings
+}
+
+func init() {
+	for _, f := range []Format{
+		{
+			Name:        "sections",
+			Description: "a section with a table per recipe",
+			Options:     layoutOptions,
+			New: func(settings map[string]string) (MarkdownGenerator, error) {
+				layout, err := parseLayout(settings)
+				if err != nil {
+					return nil, err
+				}
+				return &SectionMarkdownGenerator{Layout: layout}, nil
+			},
+		},
+		{
+			Name:        "table",
+			Description: "a single table with a row per recipe",
+			Options:     layoutOptions,
+			New: func(settings map[string]string) (MarkdownGenerator, error) {
+				layout, err := parseLayout(settings)
+				if err != nil {
+					return nil, err
+				}
+				return &TableMarkdownGenerator{Layout: layout}, nil
+			},
+		},
+		{
+			Name:        "json",
+			Description: "a JSON document of every recipe and its creators",
+			New: func(map[string]string) (MarkdownGenerator, error) {
+				return NewJSONGenerator(), nil
+			},
+		},
+		{
+			Name:        "ndjson",
+			Description: "a JSON record per line for every recipe",
+			New: func(map[string]string) (MarkdownGenerator, error) {
+				return NewNDJSONGenerator(), nil
+			},
+		},
+	} {
+		if err := RegisterFormat(f); err != nil {
+			panic(err)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+type titleGenerator struct {
+	separator string
+}
+
+func (g titleGenerator) Generate(_ context.Context, in GenerateInput) (string, error) {
+	titles := make([]string, len(in.Recipes))
+	for i, recipe := range in.Recipes {
+		titles[i] = recipe.Title
+	}
+	return strings.Join(titles, g.separator) + "\n", nil
+}
+
+func TestRegisterFormat(t *testing.T) {
+	f := Format{
+		Name:        "Titles-Test",
+		Description: "recipe titles",
+		Options:     []FormatOption{{Name: "separator", Description: "what goes between titles"}},
+		New: func(settings map[string]string) (MarkdownGenerator, error) {
+			separator, ok := settings["separator"]
+			if !ok {
+				separator = ", "
+			}
+			return titleGenerator{separator: separator}, nil
+		},
+	}
+	if err := RegisterFormat(f); err != nil {
+		t.Fatalf("RegisterFormat failed: %v", err)
+	}
+	t.Cleanup(func() {
+		formats.Lock()
+		delete(formats.byName, "titles-test")
+		formats.Unlock()
+	})
+	if err := RegisterFormat(f); err == nil {
+		t.Error("Expected an error when registering a format twice")
+	}
+
+	var names []string
+	for _, f := range Formats() {
+		names = append(names, f.Name)
+	}
+	if got, want := strings.Join(names, ","), "json,ndjson,sections,table,titles-test"; got != want {
+		t.Errorf("Expected formats %s, got %s", want, got)
+	}
+
+	generator, err := NewFormatGenerator("titles-test", map[string]string{"separator": " / "})
+	if err != nil {
+		t.Fatalf("NewFormatGenerator failed: %v", err)
+	}
+	result, err := generator.Generate(t.Context(), GenerateInput{Recipes: []*RecipeInfo{{Title: "A"}, {Title: "B"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != "A / B\n" {
+		t.Errorf("Unexpected output %q", result)
+	}
+}
+
+func TestRegisterFormat_Errors(t *testing.T) {
+	newGenerator := func(map[string]string) (MarkdownGenerator, error) { return titleGenerator{}, nil }
+	for _, f := range []Format{
+		{Name: "", New: newGenerator},
+		{Name: "list", New: newGenerator},
+		{Name: "HTML", New: newGenerator},
+		{Name: "no-constructor"},
+		{Name: "table", New: newGenerator},
+	} {
+		if err := RegisterFormat(f); err == nil {
+			t.Errorf("Expected an error registering %q", f.Name)
+		}
+	}
+}
+
+func TestNewFormatGenerator_Options(t *testing.T) {
+	tests := []struct {
+		format   string
+		settings map[string]string
+		wantErr  string
+	}{
+		{format: "table", settings: map[string]string{"group_by": "tag", "columns": "rating"}},
+		{format: "TABLE"},
+		{format: "yaml", wantErr: "invalid format specified: yaml"},
+		{format: "json", settings: map[string]string{"columns": "rating"}, wantErr: "the json format has no options"},
+		{format: "table", settings: map[string]string{"colour": "red"}, wantErr: "expected one of columns, group_by"},
+		{format: "sections", settings: map[string]string{"group_by": "colour"}, wantErr: "invalid group"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			_, err := NewFormatGenerator(tt.format, tt.settings)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("Unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("Expected an error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
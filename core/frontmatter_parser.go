@@ -2,6 +2,7 @@ package core
 
 import (
 	"bytes"
+	"fmt"
 
 	"github.com/yuin/goldmark"
 	meta "github.com/yuin/goldmark-meta"
@@ -40,3 +41,76 @@ func (gfp *GoldmarkFrontmatterParser) Extract(
 
 	return frontmatter, buf.Bytes(), nil
 }
+
+// parseFrontmatter returns the YAML frontmatter of a markdown document, or an
+// empty map when it has none. Invalid YAML is reported as an error.
+func parseFrontmatter(content []byte) (map[string]interface{}, error) {
+	markdown := goldmark.New(goldmark.WithExtensions(meta.Meta))
+	context := parser.NewContext()
+	markdown.Parser().Parse(text.NewReader(content), parser.WithContext(context))
+
+	frontmatter, err := meta.TryGet(context)
+	if err != nil {
+		return nil, err
+	}
+	if frontmatter == nil {
+		frontmatter = make(map[string]interface{})
+	}
+	return frontmatter, nil
+}
+
+// splitFrontmatter separates a leading YAML frontmatter block delimited by
+// "---" lines from the markdown body that follows it.
+func splitFrontmatter(content []byte) ([]byte, []byte) {
+	const delimiter = "---"
+
+	firstLine, rest, found := bytes.Cut(content, []byte("\n"))
+	if !found || string(bytes.TrimRight(firstLine, " \t\r")) != delimiter {
+		return nil, content
+	}
+
+	offset := len(firstLine) + 1
+	for len(rest) > 0 {
+		line, next, _ := bytes.Cut(rest, []byte("\n"))
+		lineEnd := offset + len(line) + 1
+		if string(bytes.TrimRight(line, " \t\r")) == delimiter {
+			return content[:min(lineEnd, len(content))], content[min(lineEnd, len(content)):]
+		}
+		offset = lineEnd
+		rest = next
+	}
+
+	return nil, content
+}
+
+// normalizeFrontmatter converts the map[interface{}]interface{} values the
+// YAML decoder produces for nested mappings into map[string]interface{} so
+// that frontmatter can be encoded as JSON.
+func normalizeFrontmatter(frontmatter map[string]interface{}) map[string]interface{} {
+	normalized := make(map[string]interface{}, len(frontmatter))
+	for key, value := range frontmatter {
+		normalized[key] = normalizeYAMLValue(value)
+	}
+	return normalized
+}
+
+func normalizeYAMLValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			m[fmt.Sprint(key)] = normalizeYAMLValue(item)
+		}
+		return m
+	case map[string]interface{}:
+		return normalizeFrontmatter(v)
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, item := range v {
+			s[i] = normalizeYAMLValue(item)
+		}
+		return s
+	default:
+		return v
+	}
+}
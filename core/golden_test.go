@@ -0,0 +1,251 @@
+package core
+
+import (
+	"archive/zip"
+	"bytes"
+	"flag"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/go-logr/logr/testr"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata/golden")
+
+// goldenBaseDir names the in-memory vault in paths. It is relative so that
+// paths in the output are the same on every machine.
+const goldenBaseDir = "vault"
+
+func goldenVault() fstest.MapFS {
+	files := map[string]string{
+		IgnoreFileName: "Templates/\n",
+		"Recipes/Sourdough Bread.md": "---\nfiletype: recipe\npic: bread.jpg\ncreator: \"[[Sam]]\"\n" +
+			"tags: [bread, baking]\ncourse: side\nservings: 8\nrating: 4.5\ntotal_time: 1h 30m\n" +
+			"date_added: 2024-03-01\naliases: [Bread]\n---\n# Sourdough Bread\n\n" +
+			"Feed the starter, then see [[Tomato Soup]].\n\n![[crumb.png]]\n",
+		"Recipes/Tomato Soup.md": "---\nfiletype: recipe\npic: https://example.com/soup.jpg\n" +
+			"creator:\n  - \"[[Jane Baker|Jane]]\"\n  - \"[[Sam]]\"\ntags: [soup]\ncourse: main\n" +
+			"date_added: 2023-11-12\n---\n# Tomato Soup\n\nServe with [[Bread]].\n",
+		"Recipes/Lemon Tart.md": "---\nfiletype: recipe\npic: tart.jpg\ncreator: \"[[Nobody]]\"\n---\n",
+		"Recipes/Apple Pie.md": "---\nfiletype: recipe\npic: pie.jpg\ncreator: \"[[Jane Baker]]\"\n" +
+			"tags: [dessert]\ncourse: dessert\nrating: 5\n---\n",
+		"People/Sam.md":         "---\npic: sam.jpg\n---\n",
+		"People/Jane Baker.md":  "---\npic: https://example.com/jane.jpg\naliases: [Jane]\n---\n",
+		"Notes/Shopping.md":     "Buy flour\n",
+		"Templates/Recipe.md":   "---\nfiletype: recipe\npic: template.jpg\ncreator: \"[[Sam]]\"\n---\n",
+		"Attachments/bread.jpg": "bread",
+		"Attachments/crumb.png": "crumb",
+		"Attachments/sam.jpg":   "sam",
+		"Attachments/pie.jpg":   "pie",
+	}
+
+	fsys := make(fstest.MapFS, len(files))
+	for name, content := range files {
+		fsys[name] = &fstest.MapFile{Data: []byte(content), Mode: 0o644}
+	}
+	return fsys
+}
+
+// zipVault returns fsys packed into a zip archive.
+func zipVault(t *testing.T, fsys fs.FS) fs.FS {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	if err := w.AddFS(fsys); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", "golden", name+".golden")
+	if *updateGolden {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read golden file, run go test -update to create it: %v", err)
+	}
+	if got != string(want) {
+		t.Errorf("Output differs from %s, run go test -update if the change is intended.\nGot:\n%s\nWant:\n%s",
+			path, got, want)
+	}
+}
+
+// dumpFiles lists the files of w below dir with their content, leaving out
+// the cache whose content depends on the build.
+func dumpFiles(w *MemWriter, dir string, skip ...string) string {
+	var names []string
+	for path := range w.Files {
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		if strings.HasPrefix(rel, CacheDirName+"/") || containsString(skip, rel) {
+			continue
+		}
+		names = append(names, rel)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString("==> " + name + " <==\n")
+		b.Write(w.Files[filepath.Join(dir, filepath.FromSlash(name))])
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func TestGenerate_Golden(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		opts   GenerateOptions
+	}{
+		{name: "sections", format: "sections"},
+		{name: "table", format: "table"},
+		{name: "json", format: "json"},
+		{name: "ndjson", format: "ndjson"},
+		{
+			name:   "placeholder-sorted",
+			format: "table",
+			opts: GenerateOptions{
+				MissingCreator:   MissingCreatorPlaceholder,
+				PlaceholderImage: "unknown.png",
+				Sort:             "rating:desc,title",
+			},
+		},
+		{name: "filtered", format: "sections", opts: GenerateOptions{Filter: "course = main or tag = dessert"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			generator, err := NewMarkdownGenerator(tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w := NewMemWriter()
+			opts := tt.opts
+			opts.BaseDir = goldenBaseDir
+			opts.FS = goldenVault()
+			opts.Writer = w
+			if err := GenerateMarkdownWithOptions(t.Context(), testr.New(t), opts, generator); err != nil {
+				t.Fatalf("GenerateMarkdownWithOptions failed: %v", err)
+			}
+
+			content, err := w.ReadFile(filepath.Join(goldenBaseDir, IndexFileName))
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, tt.name, string(content))
+		})
+	}
+}
+
+func TestGenerateHTMLSite_Golden(t *testing.T) {
+	w := NewMemWriter()
+	opts := GenerateOptions{BaseDir: goldenBaseDir, FS: goldenVault(), Writer: w}
+	outputDir := filepath.Join(goldenBaseDir, "site")
+	if err := GenerateHTMLSite(t.Context(), testr.New(t), opts, outputDir); err != nil {
+		t.Fatalf("GenerateHTMLSite failed: %v", err)
+	}
+	checkGolden(t, "html", dumpFiles(w, outputDir, htmlStyleSheet))
+}
+
+// TestGenerate_ZipVault checks that a zipped vault produces the same index
+// as the vault it was made from, and that a cached second run through the
+// same writer does too.
+func TestGenerate_ZipVault(t *testing.T) {
+	logger := testr.New(t)
+	w := NewMemWriter()
+	opts := GenerateOptions{BaseDir: goldenBaseDir, FS: zipVault(t, goldenVault()), Writer: w}
+	outputPath := filepath.Join(goldenBaseDir, IndexFileName)
+
+	for run := range 2 {
+		if err := GenerateMarkdownWithOptions(t.Context(), logger, opts, NewTableMarkdownGenerator()); err != nil {
+			t.Fatalf("GenerateMarkdownWithOptions failed: %v", err)
+		}
+		content, err := w.ReadFile(outputPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkGolden(t, "table", string(content))
+		if _, err := w.ReadFile(CachePath(goldenBaseDir)); err != nil {
+			t.Errorf("Expected the cache to be written after run %d: %v", run+1, err)
+		}
+	}
+}
+
+// TestGenerate_ExistingOutput checks that content around the generated
+// block of an index found in the writer is kept.
+func TestGenerate_ExistingOutput(t *testing.T) {
+	w := NewMemWriter()
+	outputPath := filepath.Join(goldenBaseDir, IndexFileName)
+	existing := "# My recipes\n\n" + BeginMarker + "\nold\n" + EndMarker + "\n\nNotes kept by hand.\n"
+	if err := w.WriteFile(outputPath, []byte(existing)); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := GenerateOptions{BaseDir: goldenBaseDir, FS: goldenVault(), Writer: w, NoCache: true}
+	if err := GenerateMarkdownWithOptions(t.Context(), testr.New(t), opts, NewTableMarkdownGenerator()); err != nil {
+		t.Fatalf("GenerateMarkdownWithOptions failed: %v", err)
+	}
+
+	content, err := w.ReadFile(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(content)
+	if !strings.HasPrefix(got, "# My recipes\n\n") || !strings.HasSuffix(got, "\n\nNotes kept by hand.\n") {
+		t.Errorf("Expected the hand-written content to be kept. Got:\n%s", got)
+	}
+	if strings.Contains(got, "\nold\n") || !strings.Contains(got, "[[Apple Pie]]") {
+		t.Errorf("Expected the generated block to be replaced. Got:\n%s", got)
+	}
+	if _, err := w.ReadFile(CachePath(goldenBaseDir)); err == nil {
+		t.Error("Did not expect a cache with NoCache")
+	}
+}
+
+func TestLint_FS(t *testing.T) {
+	diagnostics, err := Lint(testr.New(t), LintOptions{BaseDir: goldenBaseDir, FS: goldenVault()})
+	if err != nil {
+		t.Fatalf("Lint failed: %v", err)
+	}
+	var lines []string
+	for _, d := range diagnostics {
+		lines = append(lines, d.String())
+	}
+	checkGolden(t, "lint", strings.Join(lines, "\n")+"\n")
+}
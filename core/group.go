@@ -0,0 +1,159 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// GroupBy selects how the sections and table formats group recipes under
+// headings.
+type GroupBy string
+
+const (
+	GroupByNone      GroupBy = ""
+	GroupByCreator   GroupBy = "creator"
+	GroupByTag       GroupBy = "tag"
+	GroupByCuisine   GroupBy = "cuisine"
+	GroupByCourse    GroupBy = "course"
+	GroupByLetter    GroupBy = "letter"
+	GroupByYearAdded GroupBy = "year-added"
+)
+
+// groupFallbacks are the groups of recipes that have no value to group by.
+var groupFallbacks = map[GroupBy]string{
+	GroupByTag:       "Untagged",
+	GroupByCuisine:   "No cuisine",
+	GroupByCourse:    "No course",
+	GroupByLetter:    "Other",
+	GroupByYearAdded: "No date",
+}
+
+// ParseGroupBy parses the name of a grouping. An empty name selects
+// GroupByNone.
+func ParseGroupBy(s string) (GroupBy, error) {
+	switch by := GroupBy(strings.ToLower(strings.TrimSpace(s))); by {
+	case GroupByNone, GroupByCreator, GroupByTag, GroupByCuisine, GroupByCourse, GroupByLetter, GroupByYearAdded:
+		return by, nil
+	default:
+		return "", fmt.Errorf("invalid group %q, expected creator, tag, cuisine, course, letter or year-added", s)
+	}
+}
+
+// Layout customizes the sections and table formats.
+type Layout struct {
+	// Columns are extra metadata columns shown next to the creators.
+	Columns []Column
+	// GroupBy puts the recipes under a heading per group.
+	GroupBy GroupBy
+}
+
+// recipeGroup is a heading of the index with the recipes listed under it.
+// The only group of an ungrouped index has no name.
+type recipeGroup struct {
+	Name    string
+	Recipes []*RecipeInfo
+}
+
+// groupRecipes puts recipes into groups ordered by name, compared like text
+// sort keys, keeping the order of the recipes within each group. A recipe
+// with several values, such as several tags, is listed in each of their
+// groups. Recipes without a value are collected in a last group.
+func groupRecipes(recipes []*RecipeInfo, creators map[string]*CreatorInfo, by GroupBy) []recipeGroup {
+	if by == GroupByNone {
+		return []recipeGroup{{Recipes: recipes}}
+	}
+
+	var (
+		groups   []*recipeGroup
+		byKey    = make(map[string]*recipeGroup)
+		fallback = &recipeGroup{Name: groupFallbacks[by]}
+	)
+	for _, recipe := range recipes {
+		names := groupNames(recipe, creators, by)
+		if len(names) == 0 {
+			fallback.Recipes = append(fallback.Recipes, recipe)
+			continue
+		}
+
+		seen := make(map[string]bool, len(names))
+		for _, name := range names {
+			key := strings.ToLower(name)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			group, ok := byKey[key]
+			if !ok {
+				group = &recipeGroup{Name: name}
+				byKey[key] = group
+				groups = append(groups, group)
+			}
+			group.Recipes = append(group.Recipes, recipe)
+		}
+	}
+
+	col := newCollator()
+	sort.SliceStable(groups, func(i, j int) bool {
+		return col.CompareString(groups[i].Name, groups[j].Name) < 0
+	})
+	if len(fallback.Recipes) > 0 {
+		groups = append(groups, fallback)
+	}
+
+	result := make([]recipeGroup, len(groups))
+	for i, group := range groups {
+		result[i] = *group
+	}
+	return result
+}
+
+// groupNames returns the names of the groups recipe belongs to.
+func groupNames(recipe *RecipeInfo, creators map[string]*CreatorInfo, by GroupBy) []string {
+	switch by {
+	case GroupByCreator:
+		var names []string
+		for _, ref := range recipeCreators(recipe, creators) {
+			names = append(names, ref.Name)
+		}
+		return names
+	case GroupByTag:
+		return recipe.Tags
+	case GroupByCuisine:
+		return nonEmpty(recipe.Cuisine)
+	case GroupByCourse:
+		return nonEmpty(recipe.Course)
+	case GroupByLetter:
+		if r, _ := utf8.DecodeRuneInString(recipe.Title); unicode.IsLetter(r) {
+			return []string{baseLetter(r)}
+		}
+		return nil
+	case GroupByYearAdded:
+		if recipe.DateAdded.IsZero() {
+			return nil
+		}
+		return []string{strconv.Itoa(recipe.DateAdded.Year())}
+	default:
+		return nil
+	}
+}
+
+// baseLetter returns the upper case form of r without diacritics, so that
+// Éclair is listed under E.
+func baseLetter(r rune) string {
+	base, _ := utf8.DecodeRuneInString(norm.NFD.String(string(r)))
+	return string(unicode.ToUpper(base))
+}
+
+func nonEmpty(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return []string{s}
+}
@@ -0,0 +1,126 @@
+package core
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/testr"
+)
+
+func TestGroupRecipes(t *testing.T) {
+	pie := &RecipeInfo{Title: "Apple Pie", Creators: []Wikilink{{Target: "Jane"}, {Target: "Sam"}},
+		RecipeMetadata: RecipeMetadata{Tags: []string{"dessert", "Baking"}, DateAdded: time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)}}
+	bread := &RecipeInfo{Title: "bread", Creators: []Wikilink{{Target: "Sam"}},
+		RecipeMetadata: RecipeMetadata{Tags: []string{"baking"}, Cuisine: "French"}}
+	soup := &RecipeInfo{Title: "7 Bean Soup", Creators: []Wikilink{{Target: "Jane"}}}
+	recipes := []*RecipeInfo{soup, pie, bread}
+	creators := map[string]*CreatorInfo{"Jane": {Name: "Jane"}, "Sam": {Name: "Sam"}}
+
+	tests := []struct {
+		by   GroupBy
+		want string
+	}{
+		{by: GroupByNone, want: ": 7 Bean Soup, Apple Pie, bread"},
+		{by: GroupByCreator, want: "Jane: 7 Bean Soup, Apple Pie; Sam: Apple Pie, bread"},
+		{by: GroupByTag, want: "Baking: Apple Pie, bread; dessert: Apple Pie; Untagged: 7 Bean Soup"},
+		{by: GroupByCuisine, want: "French: bread; No cuisine: 7 Bean Soup, Apple Pie"},
+		{by: GroupByLetter, want: "A: Apple Pie; B: bread; Other: 7 Bean Soup"},
+		{by: GroupByYearAdded, want: "2023: Apple Pie; No date: 7 Bean Soup, bread"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.by), func(t *testing.T) {
+			var got []string
+			for _, group := range groupRecipes(recipes, creators, tt.by) {
+				titles := make([]string, len(group.Recipes))
+				for i, recipe := range group.Recipes {
+					titles[i] = recipe.Title
+				}
+				got = append(got, group.Name+": "+strings.Join(titles, ", "))
+			}
+			if strings.Join(got, "; ") != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, strings.Join(got, "; "))
+			}
+		})
+	}
+
+	if _, err := ParseGroupBy("month"); err == nil {
+		t.Error("Expected an error for an unknown grouping")
+	}
+}
+
+func TestSectionMarkdownGenerator_GroupBy(t *testing.T) {
+	logger := testr.New(t)
+	generator := &SectionMarkdownGenerator{Layout: Layout{GroupBy: GroupByTag}}
+
+	recipes := []*RecipeInfo{{
+		Name:           "Pie",
+		Title:          "Pie",
+		ImageURL:       "pie.jpg",
+		Creators:       []Wikilink{{Target: "Jane"}},
+		Slug:           "pie",
+		RecipeMetadata: RecipeMetadata{Tags: []string{"dessert", "baking"}},
+	}}
+	creators := map[string]*CreatorInfo{"Jane": {Name: "Jane", ImageURL: "jane.jpg"}}
+
+	result, err := generator.Generate(t.Context(), GenerateInput{Logger: logger, Recipes: recipes, Creators: creators})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := `# TOC
+- [[#baking|baking]]
+  - [[#Pie|Pie]] ^pie
+- [[#dessert|dessert]]
+  - [[#Pie|Pie]]
+## baking
+
+### Pie
+[[#^pie|toc]]
+
+| [[Pie]] | [[Jane]] |
+|-|-|
+| ![[pie.jpg]]  | ![[jane.jpg]]  |
+
+
+## dessert
+
+### Pie
+`
+	if !strings.HasPrefix(result, want) {
+		t.Errorf("Unexpected result. Got:\n%s", result)
+	}
+}
+
+func TestGroupRecipes_Collation(t *testing.T) {
+	titles := []string{"Zabaglione", "Éclair", "eggs", "Ørred", "Ábsinthe Cake"}
+	var recipes []*RecipeInfo
+	for _, title := range titles {
+		recipes = append(recipes, &RecipeInfo{Title: title,
+			RecipeMetadata: RecipeMetadata{Cuisine: strings.Fields(title)[0]}})
+	}
+
+	tests := []struct {
+		by   GroupBy
+		want string
+	}{
+		{by: GroupByLetter, want: "A: Ábsinthe Cake; E: Éclair, eggs; Ø: Ørred; Z: Zabaglione"},
+		{by: GroupByCuisine, want: "Ábsinthe: Ábsinthe Cake; Éclair: Éclair; eggs: eggs; Ørred: Ørred; Zabaglione: Zabaglione"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.by), func(t *testing.T) {
+			var got []string
+			for _, group := range groupRecipes(recipes, nil, tt.by) {
+				var names []string
+				for _, recipe := range group.Recipes {
+					names = append(names, recipe.Title)
+				}
+				got = append(got, group.Name+": "+strings.Join(names, ", "))
+			}
+			if strings.Join(got, "; ") != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, strings.Join(got, "; "))
+			}
+		})
+	}
+}
@@ -0,0 +1,564 @@
+package core
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/gosimple/slug"
+)
+
+const (
+	htmlAssetsDir   = "assets"
+	htmlRecipesDir  = "recipes"
+	htmlCreatorsDir = "creators"
+	htmlStyleSheet  = "style.css"
+)
+
+// wikilinkPattern matches [[target#heading|alias]] and ![[embed|alias]].
+var wikilinkPattern = regexp.MustCompile(`(!?)\[\[([^\[\]|#^]*)([#^][^\[\]|]*)?(?:\|([^\[\]]*))?\]\]`)
+
+var imageExtensions = map[string]bool{
+	".apng": true, ".avif": true, ".bmp": true, ".gif": true, ".jpeg": true,
+	".jpg": true, ".png": true, ".svg": true, ".webp": true,
+}
+
+type htmlLink struct {
+	Text string
+	Href string
+}
+
+type htmlCard struct {
+	Title    string
+	Href     string
+	Image    string
+	Creators []htmlLink
+}
+
+type htmlPage struct {
+	Title    string
+	Root     string
+	Image    string
+	Creators []htmlLink
+	Cards    []htmlCard
+	Body     template.HTML
+}
+
+type htmlSite struct {
+	logger    logr.Logger
+	vault     vault
+	writer    Writer
+	outputDir string
+	parser    FrontmatterParser
+
+	recipes  []*RecipeInfo
+	creators map[string]*CreatorInfo
+
+	// recipeFiles and creatorFiles are the page file names of each recipe
+	// and creator, unique even when their slugs are not.
+	recipeFiles  map[*RecipeInfo]string
+	creatorFiles map[*CreatorInfo]string
+	recipePages  map[string]string
+	creatorPages map[string]string
+	attachments  map[string]string
+	assets       map[string]string
+	assetNames   map[string]bool
+}
+
+// GenerateHTMLSite renders the recipes under opts.BaseDir into a static site
+// in outputDir that only uses relative links, so it can be opened from disk
+// or served by any static file server. Scanning stops with the error of
+// ctx once it is done.
+func GenerateHTMLSite(ctx context.Context, logger logr.Logger, opts GenerateOptions, outputDir string) error {
+	logger.V(1).Info("Starting HTML generation", "baseDir", opts.BaseDir, "outputDir", outputDir)
+
+	filter, err := ParseFilter(opts.Filter)
+	if err != nil {
+		return fmt.Errorf("invalid filter: %w", err)
+	}
+	sortSpec, err := ParseSortSpec(opts.Sort)
+	if err != nil {
+		return fmt.Errorf("invalid sort: %w", err)
+	}
+
+	recipes, creators, err := collectRecipes(ctx, logger, opts, []string{outputDir})
+	if err != nil {
+		return err
+	}
+
+	recipes = selectRecipes(recipes, filter, sortSpec)
+	return writeHTMLSite(logger, opts, outputDir, recipes, creators)
+}
+
+func writeHTMLSite(
+	logger logr.Logger,
+	opts GenerateOptions,
+	outputDir string,
+	recipes []*RecipeInfo,
+	creators map[string]*CreatorInfo,
+) error {
+	site := &htmlSite{
+		logger:       logger,
+		vault:        opts.vault(),
+		writer:       opts.writer(),
+		outputDir:    outputDir,
+		parser:       NewGoldmarkFrontmatterParser(),
+		recipes:      recipes,
+		creators:     creators,
+		recipeFiles:  make(map[*RecipeInfo]string),
+		creatorFiles: make(map[*CreatorInfo]string),
+		recipePages:  make(map[string]string),
+		creatorPages: make(map[string]string),
+		assets:       make(map[string]string),
+		assetNames:   make(map[string]bool),
+	}
+
+	site.assignFiles()
+	for _, recipe := range recipes {
+		page := site.recipeFiles[recipe]
+		for _, alias := range recipe.Aliases {
+			site.recipePages[strings.ToLower(alias)] = page
+		}
+	}
+	// File names take precedence over aliases, as they do in Obsidian.
+	for _, recipe := range recipes {
+		site.recipePages[strings.ToLower(noteName(recipe.Path))] = site.recipeFiles[recipe]
+	}
+	for _, recipe := range recipes {
+		for _, ref := range recipeCreators(recipe, creators) {
+			if !ref.unknown() {
+				site.creatorPages[strings.ToLower(noteName(ref.Path))] = site.creatorFile(ref.CreatorInfo)
+			}
+		}
+	}
+
+	var err error
+	site.attachments, err = findAttachments(logger, site.vault, outputDir)
+	if err != nil {
+		return fmt.Errorf("error finding attachments: %w", err)
+	}
+
+	if err := site.write(); err != nil {
+		return err
+	}
+
+	logger.V(1).Info("HTML generation completed",
+		"outputDir", outputDir, "recipes", len(recipes), "creators", len(site.creatorPages))
+	return nil
+}
+
+// assignFiles gives every recipe and creator a page file name made from
+// its slug. Notes whose slugs collide, such as "Apple Pie" and "Apple pie!"
+// in different folders, get a numbered suffix in the order of their paths,
+// so that the names stay the same however the recipes are sorted.
+func (s *htmlSite) assignFiles() {
+	recipes := slices.Clone(s.recipes)
+	slices.SortFunc(recipes, func(a, b *RecipeInfo) int { return strings.Compare(a.Path, b.Path) })
+	taken := make(map[string]bool, len(recipes))
+	for _, recipe := range recipes {
+		s.recipeFiles[recipe] = uniqueName(taken, recipe.Slug, ".html")
+	}
+
+	var creators []*CreatorInfo
+	for _, creator := range s.creators {
+		if _, ok := s.creatorFiles[creator]; !ok {
+			s.creatorFiles[creator] = ""
+			creators = append(creators, creator)
+		}
+	}
+	slices.SortFunc(creators, func(a, b *CreatorInfo) int {
+		return cmp.Or(strings.Compare(a.Path, b.Path), strings.Compare(a.Name, b.Name))
+	})
+	taken = make(map[string]bool, len(creators))
+	for _, creator := range creators {
+		s.creatorFiles[creator] = uniqueName(taken, slug.Make(creator.Name), ".html")
+	}
+}
+
+// creatorFile returns the page file name of creator. Creators that were
+// not found, like the unknown creator, are named after their slug.
+func (s *htmlSite) creatorFile(creator *CreatorInfo) string {
+	if name, ok := s.creatorFiles[creator]; ok {
+		return name
+	}
+	return slug.Make(creator.Name) + ".html"
+}
+
+// uniqueName returns stem+ext, or stem-2+ext, stem-3+ext and so on when
+// that is taken already, ignoring case, and marks the name as taken.
+func uniqueName(taken map[string]bool, stem, ext string) string {
+	candidate := stem + ext
+	for i := 2; taken[strings.ToLower(candidate)]; i++ {
+		candidate = fmt.Sprintf("%s-%d%s", stem, i, ext)
+	}
+	taken[strings.ToLower(candidate)] = true
+	return candidate
+}
+
+func noteName(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+}
+
+// findAttachments indexes every non-markdown file in the vault by its
+// lowercased file name, which is how Obsidian resolves embeds.
+func findAttachments(logger logr.Logger, v vault, excludeDir string) (map[string]string, error) {
+	attachments := make(map[string]string)
+	excludeDir = filepath.Clean(excludeDir)
+
+	err := v.walk(func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			logger.Error(err, "Error accessing path", "path", path)
+			return nil
+		}
+		if d.IsDir() {
+			if isSkippedDir(path) || filepath.Clean(path) == excludeDir {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(strings.ToLower(d.Name()), ".md") {
+			return nil
+		}
+
+		key := strings.ToLower(d.Name())
+		if _, ok := attachments[key]; !ok {
+			attachments[key] = path
+		}
+		return nil
+	})
+
+	logger.V(1).Info("Found attachments", "count", len(attachments))
+	return attachments, err
+}
+
+func (s *htmlSite) write() error {
+	if err := s.writeFile(htmlStyleSheet, []byte(htmlStyle)); err != nil {
+		return err
+	}
+
+	if err := s.writePage("index.html", "index", htmlPage{
+		Title: "Recipes",
+		Root:  "",
+		Cards: s.cards(s.recipes, ""),
+	}); err != nil {
+		return err
+	}
+
+	for _, recipe := range s.recipes {
+		if err := s.writeRecipePage(recipe); err != nil {
+			return err
+		}
+	}
+
+	written := make(map[*CreatorInfo]bool)
+	for _, creator := range s.creators {
+		if written[creator] {
+			continue
+		}
+		written[creator] = true
+
+		var recipes []*RecipeInfo
+		for _, recipe := range s.recipes {
+			if containsCreator(recipeCreators(recipe, s.creators), creator) {
+				recipes = append(recipes, recipe)
+			}
+		}
+		if len(recipes) == 0 {
+			continue
+		}
+
+		page := htmlPage{
+			Title: creator.Name,
+			Root:  "../",
+			Image: s.imageSrc(creator.ImageURL, creator.IsRemoteImage, "../"),
+			Cards: s.cards(recipes, "../"),
+		}
+		name := filepath.Join(htmlCreatorsDir, s.creatorFile(creator))
+		if err := s.writePage(name, "creator", page); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *htmlSite) writeRecipePage(recipe *RecipeInfo) error {
+	content, err := s.vault.readFile(s.logger, recipe.Path)
+	if err != nil {
+		return fmt.Errorf("error reading recipe %s: %w", recipe.Path, err)
+	}
+
+	_, body := splitFrontmatter(content)
+	body = s.rewriteWikilinks(body, "../")
+
+	_, rendered, err := s.parser.Extract(body)
+	if err != nil {
+		return fmt.Errorf("error rendering recipe %s: %w", recipe.Path, err)
+	}
+
+	page := htmlPage{
+		Title: recipe.Title,
+		Root:  "../",
+		Image: s.imageSrc(recipe.ImageURL, recipe.IsRemoteImage, "../"),
+		// Goldmark escapes raw HTML in the note unless WithUnsafe is set.
+		Body: template.HTML(rendered),
+	}
+	page.Creators = s.creatorLinks(recipe, "../")
+
+	return s.writePage(filepath.Join(htmlRecipesDir, s.recipeFiles[recipe]), "recipe", page)
+}
+
+func (s *htmlSite) cards(recipes []*RecipeInfo, root string) []htmlCard {
+	cards := make([]htmlCard, 0, len(recipes))
+	for _, recipe := range recipes {
+		card := htmlCard{
+			Title:    recipe.Title,
+			Href:     root + htmlRecipesDir + "/" + url.PathEscape(s.recipeFiles[recipe]),
+			Image:    s.imageSrc(recipe.ImageURL, recipe.IsRemoteImage, root),
+			Creators: s.creatorLinks(recipe, root),
+		}
+		cards = append(cards, card)
+	}
+	return cards
+}
+
+func (s *htmlSite) creatorLinks(recipe *RecipeInfo, root string) []htmlLink {
+	var links []htmlLink
+	for _, ref := range recipeCreators(recipe, s.creators) {
+		text := ref.Link.Display()
+		if ref.unknown() {
+			text = ref.Name
+		}
+		links = append(links, htmlLink{Text: text, Href: s.creatorHref(ref.CreatorInfo, root)})
+	}
+	return links
+}
+
+func (s *htmlSite) creatorHref(creator *CreatorInfo, root string) string {
+	return root + htmlCreatorsDir + "/" + url.PathEscape(s.creatorFile(creator))
+}
+
+// rewriteWikilinks turns Obsidian wikilinks and embeds into regular markdown
+// links relative to a page whose path to the site root is root.
+func (s *htmlSite) rewriteWikilinks(body []byte, root string) []byte {
+	return wikilinkPattern.ReplaceAllFunc(body, func(match []byte) []byte {
+		link := ParseWikilink(string(match))
+		embed, target, alias := link.Embed, link.Target, link.Alias
+
+		name := filepath.Base(filepath.FromSlash(target))
+		text := alias
+		if text == "" {
+			text = noteName(name)
+		}
+
+		if embed && imageExtensions[strings.ToLower(filepath.Ext(name))] {
+			src := s.imageSrc(target, false, root)
+			if src == "" {
+				return []byte(markdownEscape(name))
+			}
+			// An embed alias such as ![[photo.jpg|300]] is a size, not alt text.
+			if _, err := strconv.Atoi(strings.Split(alias, "x")[0]); err == nil || alias == "" {
+				text = name
+			}
+			return fmt.Appendf(nil, "![%s](%s)", markdownEscape(text), src)
+		}
+
+		key := strings.ToLower(noteName(name))
+		if page, ok := s.recipePages[key]; ok {
+			return fmt.Appendf(nil, "[%s](%s%s/%s)",
+				markdownEscape(text), root, htmlRecipesDir, url.PathEscape(page))
+		}
+		if page, ok := s.creatorPages[key]; ok {
+			return fmt.Appendf(nil, "[%s](%s%s/%s)",
+				markdownEscape(text), root, htmlCreatorsDir, url.PathEscape(page))
+		}
+
+		s.logger.V(2).Info("Leaving unresolved wikilink as text", "target", target)
+		return []byte(markdownEscape(text))
+	})
+}
+
+// imageSrc returns the src attribute for an image relative to a page whose
+// path to the site root is root, copying local images into the assets
+// directory. It returns an empty string when a local image cannot be found.
+func (s *htmlSite) imageSrc(image string, isRemote bool, root string) string {
+	if image == "" {
+		return ""
+	}
+	if isRemote {
+		return image
+	}
+
+	image = strings.Trim(image, "[]!")
+	source := s.vault.path(image)
+	if info, err := s.vault.stat(source); err != nil || info.IsDir() {
+		var ok bool
+		source, ok = s.attachments[strings.ToLower(filepath.Base(image))]
+		if !ok {
+			s.logger.V(1).Info("Image not found in vault", "image", image)
+			return ""
+		}
+	}
+
+	name, ok := s.assets[source]
+	if !ok {
+		name = s.assetName(filepath.Base(source))
+		content, err := s.vault.readFile(s.logger, source)
+		if err != nil {
+			return ""
+		}
+		if err := s.writeFile(filepath.Join(htmlAssetsDir, name), content); err != nil {
+			s.logger.Error(err, "Failed to copy image", "source", source)
+			return ""
+		}
+		s.assets[source] = name
+	}
+
+	return root + htmlAssetsDir + "/" + url.PathEscape(name)
+}
+
+// assetName picks a unique file name in the assets directory for images that
+// share a name but live in different folders of the vault.
+func (s *htmlSite) assetName(name string) string {
+	ext := filepath.Ext(name)
+	return uniqueName(s.assetNames, strings.TrimSuffix(name, ext), ext)
+}
+
+func (s *htmlSite) writePage(name, tmpl string, page htmlPage) error {
+	var buf bytes.Buffer
+	if err := htmlTemplates.ExecuteTemplate(&buf, tmpl, page); err != nil {
+		return fmt.Errorf("error rendering %s: %w", name, err)
+	}
+	return s.writeFile(name, buf.Bytes())
+}
+
+func (s *htmlSite) writeFile(name string, content []byte) error {
+	path := filepath.Join(s.outputDir, name)
+	if err := writeFile(s.logger, s.writer, path, content); err != nil {
+		return fmt.Errorf("error writing %s: %w", path, err)
+	}
+	return nil
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, `[`, `\[`, `]`, `\]`, `*`, `\*`, `_`, `\_`, "`", "\\`",
+)
+
+func markdownEscape(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+var htmlTemplates = template.Must(template.New("site").Parse(`
+{{- define "head" -}}
+<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<link rel="stylesheet" href="{{.Root}}style.css">
+</head>
+<body>
+<nav><a href="{{.Root}}index.html">All recipes</a></nav>
+{{- end -}}
+
+{{- define "cards" -}}
+<ul class="cards">
+{{- range .}}
+<li class="card">
+<a href="{{.Href}}">
+{{- if .Image}}<img src="{{.Image}}" alt="{{.Title}}" loading="lazy">{{end -}}
+<span class="title">{{.Title}}</span></a>
+{{- range .Creators}}
+<a class="creator" href="{{.Href}}">{{.Text}}</a>
+{{- end}}
+</li>
+{{- end}}
+</ul>
+{{- end -}}
+
+{{- define "index" -}}
+{{template "head" .}}
+<main>
+<h1>{{.Title}}</h1>
+{{template "cards" .Cards}}
+</main>
+</body>
+</html>
+{{end -}}
+
+{{- define "creator" -}}
+{{template "head" .}}
+<main>
+<header class="creator">
+{{- if .Image}}<img src="{{.Image}}" alt="{{.Title}}">{{end}}
+<h1>{{.Title}}</h1>
+</header>
+{{template "cards" .Cards}}
+</main>
+</body>
+</html>
+{{end -}}
+
+{{- define "recipe" -}}
+{{template "head" .}}
+<main>
+<article>
+<h1>{{.Title}}</h1>
+{{- with .Creators}}
+<p class="byline">by {{range $i, $c := .}}{{if $i}}, {{end}}<a href="{{$c.Href}}">{{$c.Text}}</a>{{end}}</p>
+{{- end}}
+{{- if .Image}}
+<img class="hero" src="{{.Image}}" alt="{{.Title}}">
+{{- end}}
+{{.Body}}
+</article>
+</main>
+</body>
+</html>
+{{end -}}
+`))
+
+const htmlStyle = `body {
+  font-family: system-ui, sans-serif;
+  margin: 0 auto;
+  max-width: 72rem;
+  padding: 1rem;
+  line-height: 1.5;
+  color: #222;
+}
+nav { margin-bottom: 1rem; }
+img { max-width: 100%; height: auto; }
+.cards {
+  display: grid;
+  grid-template-columns: repeat(auto-fill, minmax(14rem, 1fr));
+  gap: 1rem;
+  list-style: none;
+  padding: 0;
+}
+.card {
+  border: 1px solid #ddd;
+  border-radius: 0.5rem;
+  overflow: hidden;
+  display: flex;
+  flex-direction: column;
+}
+.card a { color: inherit; text-decoration: none; }
+.card img { width: 100%; aspect-ratio: 4 / 3; object-fit: cover; display: block; }
+.card .title { display: block; padding: 0.5rem 0.75rem 0; font-weight: 600; }
+.card .creator { padding: 0 0.75rem 0.75rem; color: #666; font-size: 0.9rem; }
+header.creator { display: flex; align-items: center; gap: 1rem; }
+header.creator img { width: 6rem; height: 6rem; object-fit: cover; border-radius: 50%; }
+article { max-width: 48rem; }
+article .hero { border-radius: 0.5rem; }
+`
@@ -0,0 +1,141 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/go-logr/logr/testr"
+)
+
+func TestGenerateHTMLSite(t *testing.T) {
+	logger := testr.New(t)
+	baseDir := t.TempDir()
+	outputDir := filepath.Join(t.TempDir(), "site")
+
+	files := map[string]string{
+		"Jane Baker.md": "---\npic: https://example.com/jane.jpg\n---\n",
+		"Recipes/Apple Pie.md": "---\nfiletype: recipe\npic: pie.jpg\ncreator: \"[[Jane Baker]]\"\n---\n" +
+			"From [[Jane Baker|Jane]], goes well with [[Custard]] and [[Missing]].\n\n![[pie.jpg|300]]\n",
+		"Recipes/Custard.md":     "---\nfiletype: recipe\ncreator: Jane Baker\n---\n",
+		"attachments/pie.jpg":    "jpeg",
+		"attachments/unused.png": "png",
+	}
+	for name, content := range files {
+		path := filepath.Join(baseDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := GenerateOptions{BaseDir: baseDir, NoCache: true}
+	if err := GenerateHTMLSite(t.Context(), logger, opts, outputDir); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	read := func(name string) string {
+		t.Helper()
+		content, err := os.ReadFile(filepath.Join(outputDir, name))
+		if err != nil {
+			t.Fatalf("Expected %s to be written: %v", name, err)
+		}
+		return string(content)
+	}
+
+	index := read("index.html")
+	for _, want := range []string{
+		`<a href="recipes/apple-pie.html"><img src="assets/pie.jpg" alt="Apple Pie"`,
+		`<a class="creator" href="creators/jane-baker.html">Jane Baker</a>`,
+		`<a href="recipes/custard.html"><span class="title">Custard</span></a>`,
+	} {
+		if !strings.Contains(index, want) {
+			t.Errorf("Expected index.html to contain %q. Got:\n%s", want, index)
+		}
+	}
+
+	recipe := read("recipes/apple-pie.html")
+	for _, want := range []string{
+		`From <a href="../creators/jane-baker.html">Jane</a>`,
+		`<a href="../recipes/custard.html">Custard</a> and Missing.`,
+		`<img src="../assets/pie.jpg" alt="pie.jpg">`,
+	} {
+		if !strings.Contains(recipe, want) {
+			t.Errorf("Expected apple-pie.html to contain %q. Got:\n%s", want, recipe)
+		}
+	}
+
+	creator := read("creators/jane-baker.html")
+	if !strings.Contains(creator, `<img src="https://example.com/jane.jpg" alt="Jane Baker">`) {
+		t.Errorf("Expected creator page to show remote image. Got:\n%s", creator)
+	}
+
+	if read("assets/pie.jpg") != "jpeg" {
+		t.Error("Expected local image to be copied into assets")
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, "assets", "unused.png")); err == nil {
+		t.Error("Expected unreferenced attachments not to be copied")
+	}
+}
+
+func TestGenerateHTMLSite_SlugCollisions(t *testing.T) {
+	fsys := fstest.MapFS{
+		"Desserts/Apple Pie.md": {Data: []byte("---\nfiletype: recipe\ncreator: \"[[Jane Baker]]\"\n---\nSweet.\n")},
+		"Mains/Apple pie!.md":   {Data: []byte("---\nfiletype: recipe\ncreator: \"[[Jane-Baker]]\"\n---\nSavoury.\n")},
+		"Jane Baker.md":         {Data: []byte("---\npic: jane.jpg\n---\n")},
+		"Jane-Baker.md":         {Data: []byte("---\npic: other.jpg\n---\n")},
+	}
+	w := NewMemWriter()
+	opts := GenerateOptions{BaseDir: "vault", FS: fsys, Writer: w}
+	outputDir := filepath.Join("vault", "site")
+	if err := GenerateHTMLSite(t.Context(), testr.New(t), opts, outputDir); err != nil {
+		t.Fatalf("GenerateHTMLSite failed: %v", err)
+	}
+
+	read := func(name string) string {
+		t.Helper()
+		content, err := w.ReadFile(filepath.Join(outputDir, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatalf("Expected %s to be written: %v", name, err)
+		}
+		return string(content)
+	}
+
+	if got := read("recipes/apple-pie.html"); !strings.Contains(got, "Sweet.") {
+		t.Errorf("Expected apple-pie.html to be the first recipe by path, got:\n%s", got)
+	}
+	if got := read("recipes/apple-pie-2.html"); !strings.Contains(got, "Savoury.") {
+		t.Errorf("Expected apple-pie-2.html to be the second recipe by path, got:\n%s", got)
+	}
+	read("creators/jane-baker.html")
+	read("creators/jane-baker-2.html")
+
+	index := read("index.html")
+	for _, want := range []string{`href="recipes/apple-pie.html"`, `href="recipes/apple-pie-2.html"`,
+		`href="creators/jane-baker.html"`, `href="creators/jane-baker-2.html"`} {
+		if !strings.Contains(index, want) {
+			t.Errorf("Expected index.html to link %s, got:\n%s", want, index)
+		}
+	}
+}
+
+func TestGenerateHTMLSite_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	w := NewMemWriter()
+	opts := GenerateOptions{BaseDir: goldenBaseDir, FS: goldenVault(), Writer: w, Jobs: 1}
+	err := GenerateHTMLSite(ctx, testr.New(t), opts, filepath.Join(goldenBaseDir, "site"))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	if len(w.Files) != 0 {
+		t.Errorf("Did not expect a cancelled export to write anything, got %s", dumpFiles(w, goldenBaseDir))
+	}
+}
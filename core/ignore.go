@@ -0,0 +1,217 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-logr/logr"
+)
+
+// IgnoreFileName is the file in the base directory listing paths to leave
+// out of the scan, written like a .gitignore file.
+const IgnoreFileName = ".wholeoverrideignore"
+
+// WalkOptions narrows down the files FindMarkdownFilesWithOptions returns.
+// Patterns are written like the lines of a .gitignore file and are matched
+// against paths relative to the base directory.
+type WalkOptions struct {
+	// Include, when set, limits the scan to files matching any of these
+	// patterns or below a directory that does.
+	Include []string
+	// Exclude skips the files and directories matching these patterns, in
+	// addition to those listed in IgnoreFileName.
+	Exclude []string
+	// Outputs are the files and directories the tool writes to, which are
+	// never scanned.
+	Outputs []string
+}
+
+// ignorePattern is a line of an ignore file.
+type ignorePattern struct {
+	segments []string
+	negate   bool
+	dirOnly  bool
+}
+
+// parseIgnorePattern parses a line with gitignore semantics: a pattern
+// without a slash matches at any depth, a leading or inner slash anchors it
+// to the base directory, a trailing slash only matches directories and a
+// leading ! re-includes what earlier patterns excluded. Blank lines and
+// comments yield no pattern.
+func parseIgnorePattern(line string) (ignorePattern, bool, error) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignorePattern{}, false, nil
+	}
+
+	var p ignorePattern
+	switch {
+	case strings.HasPrefix(line, "!"):
+		p.negate = true
+		line = line[1:]
+	case strings.HasPrefix(line, `\!`), strings.HasPrefix(line, `\#`):
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if strings.Trim(line, "/") == "" {
+		return ignorePattern{}, false, nil
+	}
+	if !strings.Contains(line, "/") {
+		line = "**/" + line
+	}
+	line = strings.TrimPrefix(line, "/")
+
+	p.segments = strings.Split(line, "/")
+	for _, segment := range p.segments {
+		if _, err := path.Match(segment, ""); err != nil {
+			return ignorePattern{}, false, err
+		}
+	}
+	return p, true, nil
+}
+
+func (p ignorePattern) match(rel string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+	return matchSegments(p.segments, strings.Split(rel, "/"))
+}
+
+// ignoreList is a list of patterns where the last matching one decides.
+type ignoreList []ignorePattern
+
+func parseIgnoreList(lines []string) (ignoreList, error) {
+	var list ignoreList
+	for i, line := range lines {
+		p, ok, err := parseIgnorePattern(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid pattern %q: %w", i+1, line, err)
+		}
+		if ok {
+			list = append(list, p)
+		}
+	}
+	return list, nil
+}
+
+func (l ignoreList) matches(rel string, isDir bool) bool {
+	matched := false
+	for _, p := range l {
+		if p.match(rel, isDir) {
+			matched = !p.negate
+		}
+	}
+	return matched
+}
+
+// matchesWithin reports whether rel or any directory above it matches.
+func (l ignoreList) matchesWithin(rel string, isDir bool) bool {
+	if l.matches(rel, isDir) {
+		return true
+	}
+	for dir := path.Dir(rel); dir != "."; dir = path.Dir(dir) {
+		if l.matches(dir, true) {
+			return true
+		}
+	}
+	return false
+}
+
+// walkFilter decides which paths below baseDir are scanned.
+type walkFilter struct {
+	baseDir string
+	exclude ignoreList
+	include ignoreList
+	// outputs are the outputs below baseDir relative to it.
+	outputs map[string]bool
+}
+
+func newWalkFilter(logger logr.Logger, v vault, opts WalkOptions) (*walkFilter, error) {
+	baseDir := v.baseDir
+	f := &walkFilter{baseDir: baseDir, outputs: make(map[string]bool)}
+
+	content, err := fs.ReadFile(v.fsys, IgnoreFileName)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("failed to read %s: %w", IgnoreFileName, err)
+	default:
+		logger.V(1).Info("Using ignore file", "path", v.path(IgnoreFileName))
+	}
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if f.exclude, err = parseIgnoreList(lines); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", IgnoreFileName, err)
+	}
+
+	for _, pattern := range opts.Exclude {
+		excluded, err := parseIgnoreList([]string{pattern})
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclude pattern %q: %w", pattern, err)
+		}
+		f.exclude = append(f.exclude, excluded...)
+	}
+	for _, pattern := range opts.Include {
+		included, err := parseIgnoreList([]string{pattern})
+		if err != nil {
+			return nil, fmt.Errorf("invalid include pattern %q: %w", pattern, err)
+		}
+		f.include = append(f.include, included...)
+	}
+
+	absBase, err := filepath.Abs(baseDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, output := range append([]string{filepath.Join(baseDir, IndexFileName)}, opts.Outputs...) {
+		if output == StdoutPath {
+			continue
+		}
+		abs, err := filepath.Abs(output)
+		if err != nil {
+			continue
+		}
+		if rel, err := filepath.Rel(absBase, abs); err == nil && rel != ".." &&
+			!strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			f.outputs[filepath.ToSlash(rel)] = true
+		}
+	}
+
+	return f, nil
+}
+
+// skip returns why the file or directory at path is left out of the scan,
+// or "" when it is scanned. Directories are still walked when they are not
+// included, because files below them may be.
+func (f *walkFilter) skip(p string, isDir bool) string {
+	rel, err := filepath.Rel(f.baseDir, p)
+	if err != nil || rel == "." {
+		return ""
+	}
+	rel = filepath.ToSlash(rel)
+
+	switch {
+	case isDir && isSkippedDir(p):
+		return "skipped directory"
+	case f.outputs[rel]:
+		return "output of wholeoverride"
+	case f.exclude.matches(rel, isDir):
+		return "excluded"
+	case !isDir && len(f.include) > 0 && !f.include.matchesWithin(rel, false):
+		return "not included"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,105 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+)
+
+func TestFindMarkdownFilesWithOptions(t *testing.T) {
+	baseDir := t.TempDir()
+	for _, name := range []string{
+		"Bread.md",
+		"recipeindex.md",
+		"Indexes/vegan.md",
+		"Digital.Gitbook/Notes.md",
+		"My.trash.can/Soup.md",
+		".git/HEAD.md",
+		".trash/Old.md",
+		".obsidian/Workspace.md",
+		"Drafts/Pie.md",
+		"Drafts/Keep.md",
+		"Recipes/Cake.md",
+		"Recipes/Cake.draft.md",
+		"Recipes/Sub/Tart.md",
+		"Templates/Recipe.md",
+	} {
+		path := filepath.Join(baseDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("# "+name+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ignore := "# scratch space\nDrafts/*\n!Drafts/Keep.md\n/Templates/\n"
+	if err := os.WriteFile(filepath.Join(baseDir, IgnoreFileName), []byte(ignore), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		opts WalkOptions
+		want []string
+	}{
+		{
+			name: "ignore file",
+			opts: WalkOptions{Outputs: []string{filepath.Join(baseDir, "Indexes", "vegan.md")}},
+			want: []string{
+				"Bread.md", "Digital.Gitbook/Notes.md", "Drafts/Keep.md", "My.trash.can/Soup.md",
+				"Recipes/Cake.draft.md", "Recipes/Cake.md", "Recipes/Sub/Tart.md",
+			},
+		},
+		{
+			name: "exclude",
+			opts: WalkOptions{Exclude: []string{"*.draft.md", "Sub/"}},
+			want: []string{
+				"Bread.md", "Digital.Gitbook/Notes.md", "Drafts/Keep.md", "Indexes/vegan.md",
+				"My.trash.can/Soup.md", "Recipes/Cake.md",
+			},
+		},
+		{
+			name: "include",
+			opts: WalkOptions{Include: []string{"Recipes/", "Bread.md"}, Exclude: []string{"*.draft.md"}},
+			want: []string{"Bread.md", "Recipes/Cake.md", "Recipes/Sub/Tart.md"},
+		},
+		{
+			name: "anchored include",
+			opts: WalkOptions{Include: []string{"/Recipes/*.md"}},
+			want: []string{"Recipes/Cake.draft.md", "Recipes/Cake.md"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := FindMarkdownFilesWithOptions(testr.New(t), baseDir, tt.opts)
+			if err != nil {
+				t.Fatalf("FindMarkdownFilesWithOptions failed: %v", err)
+			}
+
+			got := make([]string, len(files))
+			for i, file := range files {
+				rel, err := filepath.Rel(baseDir, file)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got[i] = filepath.ToSlash(rel)
+			}
+			sort.Strings(got)
+			if got, want := strings.Join(got, "\n"), strings.Join(tt.want, "\n"); got != want {
+				t.Errorf("Unexpected files.\nGot:\n%s\nWant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestFindMarkdownFilesWithOptions_InvalidPattern(t *testing.T) {
+	baseDir := writeTestVault(t, 1, 1)
+	if _, err := FindMarkdownFilesWithOptions(testr.New(t), baseDir, WalkOptions{Exclude: []string{"Recipes/["}}); err == nil {
+		t.Error("Expected an error for an invalid pattern")
+	}
+}
@@ -0,0 +1,361 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/gosimple/slug"
+	"github.com/yuin/goldmark/ast"
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic codes reported by Lint.
+const (
+	CodeInvalidFrontmatter = "invalid-frontmatter"
+	CodeMissingPic         = "missing-pic"
+	CodeMissingImage       = "missing-image"
+	CodeEmptyCreator       = "empty-creator"
+	CodeCreatorNotFound    = "creator-not-found"
+	CodeAmbiguousCreator   = "ambiguous-creator"
+	CodeDuplicateSlug      = "duplicate-slug"
+	CodeUnknownFiletype    = "unknown-filetype"
+	CodeInvalidMetadata    = "invalid-metadata"
+)
+
+type Diagnostic struct {
+	Path     string   `json:"path"`
+	Line     int      `json:"line"`
+	Column   int      `json:"column"`
+	Severity Severity `json:"severity"`
+	Code     string   `json:"code"`
+	Message  string   `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s: %s (%s)", d.Path, d.Line, d.Column, d.Severity, d.Message, d.Code)
+}
+
+type LintOptions struct {
+	BaseDir string
+	// CreatorsDir limits creator resolution like GenerateOptions.CreatorsDir.
+	CreatorsDir string
+	// Detection decides which notes are linted as recipes and which can be
+	// creators, like GenerateOptions.Detection.
+	Detection Detection
+	// Include and Exclude narrow down the linted files, see WalkOptions.
+	Include []string
+	Exclude []string
+	// KnownFiletypes are filetype values besides "recipe" that are not
+	// reported as unknown.
+	KnownFiletypes []string
+	// FS, when set, is read instead of BaseDir like GenerateOptions.FS.
+	FS fs.FS
+}
+
+type linter struct {
+	logger      logr.Logger
+	opts        LintOptions
+	vault       vault
+	detector    noteDetector
+	known       map[string]bool
+	attachments map[string]string
+	index       *noteIndex
+	slugs       map[string]string
+	// creators are the paths of the creator notes recipes link to.
+	creators    map[string]bool
+	diagnostics []Diagnostic
+}
+
+type lintNote struct {
+	path        string
+	content     []byte
+	frontmatter map[string]interface{}
+	doc         ast.Node
+	err         error
+}
+
+// Lint checks the frontmatter of every recipe under opts.BaseDir and the
+// creator notes they reference. Diagnostics are ordered by path and line.
+func Lint(logger logr.Logger, opts LintOptions) ([]Diagnostic, error) {
+	logger.V(1).Info("Starting lint", "baseDir", opts.BaseDir)
+
+	if err := opts.Detection.Validate(); err != nil {
+		return nil, err
+	}
+
+	v := newVault(opts.BaseDir, opts.FS)
+	files, err := findMarkdownFiles(logger, v, WalkOptions{
+		Include: opts.Include,
+		Exclude: opts.Exclude,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error finding markdown files: %w", err)
+	}
+
+	attachments, err := findAttachments(logger, v, "")
+	if err != nil {
+		return nil, fmt.Errorf("error finding attachments: %w", err)
+	}
+
+	l := &linter{
+		logger:      logger,
+		opts:        opts,
+		vault:       v,
+		detector:    noteDetector{baseDir: opts.BaseDir, Detection: opts.Detection},
+		known:       map[string]bool{"recipe": true},
+		attachments: attachments,
+		index:       newNoteIndex(opts.BaseDir, opts.CreatorsDir),
+		slugs:       make(map[string]string),
+		creators:    make(map[string]bool),
+	}
+	for _, filetype := range opts.KnownFiletypes {
+		l.known[filetype] = true
+	}
+
+	notes := make([]lintNote, 0, len(files))
+	for _, file := range files {
+		content, err := v.readFile(logger, file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+		note := lintNote{path: file, content: content}
+		note.frontmatter, note.err = parseFrontmatter(content)
+		if note.err == nil {
+			note.doc = parseMarkdown(content)
+			facts := l.detector.facts(file, note.frontmatter, note.doc, content)
+			l.index.add(file, frontmatterAliases(note.frontmatter), l.detector.isCreator(facts))
+		}
+		notes = append(notes, note)
+	}
+
+	for _, note := range notes {
+		l.lintNote(note)
+	}
+	for _, note := range notes {
+		if l.creators[note.path] {
+			l.lintCreator(note)
+		}
+	}
+
+	sort.SliceStable(l.diagnostics, func(i, j int) bool {
+		a, b := l.diagnostics[i], l.diagnostics[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Line < b.Line
+	})
+
+	logger.V(1).Info("Lint completed", "files", len(files), "diagnostics", len(l.diagnostics))
+	return l.diagnostics, nil
+}
+
+func (l *linter) lintNote(note lintNote) {
+	path, content, frontmatter := note.path, note.content, note.frontmatter
+	if note.err != nil {
+		l.report(path, 1, SeverityError, CodeInvalidFrontmatter, "invalid YAML frontmatter: %v", note.err)
+		return
+	}
+
+	if !l.detector.isRecipe(l.detector.facts(path, frontmatter, note.doc, content)) {
+		l.lintFiletype(path, content, frontmatter)
+		return
+	}
+
+	title := recipeTitle(noteName(path), frontmatter, firstHeading(note.doc, content))
+	recipeSlug := slug.Make(title)
+	if first, ok := l.slugs[recipeSlug]; ok {
+		l.report(path, 1, SeverityError, CodeDuplicateSlug,
+			"title %q has the same slug %q as %s", title, recipeSlug, displayPath(first))
+	} else {
+		l.slugs[recipeSlug] = path
+	}
+
+	l.lintPic(path, content, frontmatter, "recipe")
+
+	_, errs := parseRecipeMetadata(frontmatter)
+	for _, err := range errs {
+		var fieldErr *FieldError
+		if errors.As(err, &fieldErr) {
+			l.report(path, frontmatterLine(content, fieldErr.Field), SeverityError, CodeInvalidMetadata,
+				"%v", err)
+		}
+	}
+
+	links := parseCreatorLinks(frontmatter["creator"])
+	line := frontmatterLine(content, "creator")
+	if len(links) == 0 {
+		l.report(path, line, SeverityError, CodeEmptyCreator, "recipe has no creator")
+		return
+	}
+
+	for _, link := range links {
+		var ambiguous *AmbiguousLinkError
+		creatorPath, err := l.index.resolve(link.Target)
+		switch {
+		case errors.As(err, &ambiguous):
+			l.report(path, line, SeverityError, CodeAmbiguousCreator,
+				"creator %q matches %s", link.Target, strings.Join(ambiguous.Candidates, ", "))
+		case err != nil:
+			l.report(path, line, SeverityError, CodeCreatorNotFound, "creator note %q not found", link.Target)
+		default:
+			l.creators[creatorPath] = true
+		}
+	}
+}
+
+// lintCreator checks a creator note a recipe links to. Notes that are
+// recipes themselves have been checked already.
+func (l *linter) lintCreator(note lintNote) {
+	if note.err != nil || l.detector.isRecipe(l.detector.facts(note.path, note.frontmatter, note.doc, note.content)) {
+		return
+	}
+	l.lintPic(note.path, note.content, note.frontmatter, "creator")
+}
+
+// lintPic reports a note, described as kind, without a pic or whose pic is
+// a local image that is not in the vault.
+func (l *linter) lintPic(path string, content []byte, frontmatter map[string]interface{}, kind string) {
+	pic, _ := frontmatter["pic"].(string)
+	switch {
+	case pic == "":
+		l.report(path, frontmatterLine(content, "pic"), SeverityWarning, CodeMissingPic,
+			"%s has no pic", kind)
+	case !isRemoteURL(pic) && !l.imageExists(pic):
+		l.report(path, frontmatterLine(content, "pic"), SeverityError, CodeMissingImage,
+			"image %q not found in vault", pic)
+	}
+}
+
+func (l *linter) lintFiletype(path string, content []byte, frontmatter map[string]interface{}) {
+	value, ok := frontmatter["filetype"]
+	if !ok {
+		return
+	}
+
+	fileType := fmt.Sprint(value)
+	if l.known[fileType] {
+		return
+	}
+
+	message := fmt.Sprintf("unknown filetype %q", fileType)
+	if strings.EqualFold(fileType, "recipe") {
+		message += `, did you mean "recipe"?`
+	}
+	l.report(path, frontmatterLine(content, "filetype"), SeverityWarning, CodeUnknownFiletype, "%s", message)
+}
+
+func (l *linter) imageExists(image string) bool {
+	image = strings.Trim(image, "[]!")
+	if info, err := l.vault.stat(l.vault.path(image)); err == nil && !info.IsDir() {
+		return true
+	}
+	_, ok := l.attachments[strings.ToLower(filepath.Base(image))]
+	return ok
+}
+
+// displayPath keeps paths as they were found under the base directory so
+// that diagnostics resolve relative to the working directory, which is what
+// editors and GitHub annotations expect.
+func displayPath(path string) string {
+	return filepath.ToSlash(filepath.Clean(path))
+}
+
+func (l *linter) report(path string, line int, severity Severity, code, format string, args ...interface{}) {
+	l.diagnostics = append(l.diagnostics, Diagnostic{
+		Path:     displayPath(path),
+		Line:     line,
+		Column:   1,
+		Severity: severity,
+		Code:     code,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// frontmatterLine returns the 1-based line of key in the frontmatter of
+// content, or the first line when the key is not present.
+func frontmatterLine(content []byte, key string) int {
+	front, _ := splitFrontmatter(content)
+	pattern := regexp.MustCompile(`^` + regexp.QuoteMeta(key) + `\s*:`)
+
+	scanner := bufio.NewScanner(bytes.NewReader(front))
+	for line := 1; scanner.Scan(); line++ {
+		if pattern.Match(scanner.Bytes()) {
+			return line
+		}
+	}
+	return 1
+}
+
+func HasErrors(diagnostics []Diagnostic) bool {
+	for _, d := range diagnostics {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteDiagnostics writes diagnostics as "text", "json" or "github", the
+// latter being GitHub Actions workflow commands that annotate files.
+func WriteDiagnostics(w io.Writer, diagnostics []Diagnostic, format string) error {
+	switch format {
+	case "text":
+		for _, d := range diagnostics {
+			if _, err := fmt.Fprintln(w, d); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "json":
+		if diagnostics == nil {
+			diagnostics = []Diagnostic{}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(struct {
+			Diagnostics []Diagnostic `json:"diagnostics"`
+		}{diagnostics})
+	case "github":
+		for _, d := range diagnostics {
+			_, err := fmt.Fprintf(w, "::%s file=%s,line=%d,col=%d,title=%s::%s\n",
+				d.Severity,
+				githubProperty(d.Path), d.Line, d.Column, githubProperty(d.Code),
+				githubData(d.Message))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid lint format specified: %s", format)
+	}
+}
+
+var (
+	githubDataEscaper     = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	githubPropertyEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+)
+
+func githubData(s string) string {
+	return githubDataEscaper.Replace(s)
+}
+
+func githubProperty(s string) string {
+	return githubPropertyEscaper.Replace(s)
+}
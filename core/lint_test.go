@@ -0,0 +1,95 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+)
+
+func TestLint(t *testing.T) {
+	logger := testr.New(t)
+	baseDir := t.TempDir()
+
+	files := map[string]string{
+		"Jane.md":             "---\npic: jane.jpg\n---\n",
+		"jane.jpg":            "jpeg",
+		"Good.md":             "---\nfiletype: recipe\npic: jane.jpg\ncreator: \"[[Jane]]\"\n---\n",
+		"Recipes/good.md":     "---\nfiletype: recipe\npic: https://example.com/x.jpg\ncreator: Jane\n---\n",
+		"Recipes/No Pic.md":   "---\nfiletype: recipe\ntags: [x]\nrating: 9\ncreator: Jane\n---\n",
+		"Recipes/Lost.md":     "---\nfiletype: recipe\npic: lost.png\ncreator: Nobody\n---\n",
+		"Recipes/Nameless.md": "---\nfiletype: recipe\npic: jane.jpg\ncreator: \"\"\n---\n",
+		"Notes/Typo.md":       "---\ntitle: x\nfiletype: Recipe\n---\n",
+		"Notes/Person.md":     "---\nfiletype: person\n---\n",
+		"Notes/Broken.md":     "---\nfiletype: [recipe\n---\n",
+		"People/Sam.md":       "---\naliases: [Samuel]\n---\n",
+		"People/Bo.md":        "---\npic: bo.png\n---\n",
+		"People/Unused.md":    "---\n---\n",
+		"Recipes/Pair.md":     "---\nfiletype: recipe\npic: jane.jpg\ncreator: [\"[[Sam]]\", \"[[Bo]]\"]\n---\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(baseDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	diagnostics, err := Lint(logger, LintOptions{BaseDir: baseDir, KnownFiletypes: []string{"person"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var got []string
+	for _, d := range diagnostics {
+		rel := strings.TrimPrefix(d.Path, filepath.ToSlash(baseDir)+"/")
+		got = append(got, fmt.Sprintf("%s %s %s line %d", rel, d.Severity, d.Code, d.Line))
+	}
+
+	want := []string{
+		"Notes/Broken.md error invalid-frontmatter line 1",
+		"Notes/Typo.md warning unknown-filetype line 3",
+		"People/Bo.md error missing-image line 2",
+		"People/Sam.md warning missing-pic line 1",
+		"Recipes/Lost.md error missing-image line 3",
+		"Recipes/Lost.md error creator-not-found line 4",
+		"Recipes/Nameless.md error empty-creator line 4",
+		"Recipes/No Pic.md warning missing-pic line 1",
+		"Recipes/No Pic.md error invalid-metadata line 4",
+		"Recipes/good.md error duplicate-slug line 1",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Unexpected diagnostics.\nGot:\n%s\nWant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	if !HasErrors(diagnostics) {
+		t.Error("Expected HasErrors to report errors")
+	}
+}
+
+func TestWriteDiagnostics_GitHub(t *testing.T) {
+	diagnostics := []Diagnostic{{
+		Path:     "Recipes/a,b.md",
+		Line:     3,
+		Column:   1,
+		Severity: SeverityWarning,
+		Code:     CodeMissingPic,
+		Message:  "100% missing\nreally",
+	}}
+
+	var buf bytes.Buffer
+	if err := WriteDiagnostics(&buf, diagnostics, "github"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := "::warning file=Recipes/a%2Cb.md,line=3,col=1,title=missing-pic::100%25 missing%0Areally\n"
+	if buf.String() != want {
+		t.Errorf("Unexpected output.\nGot:  %q\nWant: %q", buf.String(), want)
+	}
+}
@@ -1,88 +1,335 @@
 package core
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"os"
 	"path/filepath"
-	"sort"
 	"strings"
 
 	"github.com/go-logr/logr"
 	"github.com/gosimple/slug"
 )
 
+// MarkdownGenerator renders recipes into the content of an index. New
+// generators are made available by name with RegisterFormat.
 type MarkdownGenerator interface {
-	Generate(
-		logger logr.Logger,
-		recipes []*RecipeInfo,
-		creators map[string]*CreatorInfo,
-	) (string, error)
+	Generate(ctx context.Context, in GenerateInput) (string, error)
 }
 
-func GenerateMarkdownWithFormat(logger logr.Logger, baseDir, format string) error {
-	var generator MarkdownGenerator
-	switch format {
-	case "sections":
-		generator = NewSectionMarkdownGenerator()
-	case "table":
-		generator = NewTableMarkdownGenerator()
-	default:
-		return fmt.Errorf("invalid format specified: %s", format)
+// GenerateInput is what a MarkdownGenerator renders.
+type GenerateInput struct {
+	Logger logr.Logger
+	// Recipes are in the configured sort order.
+	Recipes []*RecipeInfo
+	// Creators are keyed by the target of the links to them.
+	Creators map[string]*CreatorInfo
+	// BaseDir is the vault the recipes were read from.
+	BaseDir string
+	// OutputPath is the file the content is written to, StdoutPath, or
+	// empty when it is not written to a file.
+	OutputPath string
+}
+
+const IndexFileName = "recipeindex.md"
+
+// StdoutPath is the output path that writes the index to standard output.
+const StdoutPath = "-"
+
+type GenerateOptions struct {
+	BaseDir string
+	// OutputPath is where the index is written. Empty means IndexFileName
+	// in BaseDir and StdoutPath means standard output.
+	OutputPath string
+	NoCache    bool
+	// Jobs is the number of files parsed concurrently. Zero or less means
+	// runtime.GOMAXPROCS(0).
+	Jobs int
+	// Filter selects the recipes to include, see ParseFilter.
+	Filter string
+	// Sort orders the recipes, see ParseSortSpec.
+	Sort string
+	// CreatorsDir, when set, limits creator resolution to notes below this
+	// directory, relative to BaseDir.
+	CreatorsDir string
+	// Detection decides which notes are recipes and which can be creators.
+	Detection Detection
+	// Include and Exclude narrow down the scanned files, see WalkOptions.
+	Include []string
+	Exclude []string
+	// MissingCreator decides what happens to recipes none of whose creators
+	// were found. Empty means MissingCreatorDrop.
+	MissingCreator MissingCreatorPolicy
+	// PlaceholderImage is the image shown for the unknown creator with the
+	// MissingCreatorPlaceholder policy.
+	PlaceholderImage string
+	// Strict fails the run with a *ScanError instead of writing an index
+	// when any recipe had to be dropped.
+	Strict bool
+	// FS, when set, is read instead of BaseDir, which then only names the
+	// vault in paths. It can be an fstest.MapFS, a zip archive or any
+	// other file system holding a vault.
+	FS fs.FS
+	// Writer receives the files the run writes, including the cache. Nil
+	// means OSWriter. When FS is set and Writer is not, nothing is cached.
+	Writer Writer
+}
+
+func (opts GenerateOptions) outputPath() string {
+	if opts.OutputPath == "" {
+		return filepath.Join(opts.BaseDir, IndexFileName)
 	}
+	return opts.OutputPath
+}
 
-	return GenerateMarkdown(logger, baseDir, generator)
+func (opts GenerateOptions) vault() vault {
+	return newVault(opts.BaseDir, opts.FS)
 }
 
-func GenerateMarkdown(logger logr.Logger, baseDir string, generator MarkdownGenerator) error {
-	logger.V(1).Info("Starting markdown generation", "baseDir", baseDir)
+// cacheEnabled reports whether parse results are cached. A vault read from
+// FS is only cached when a Writer is given too, so that nothing is written
+// to disk for a vault that isn't there.
+func (opts GenerateOptions) cacheEnabled() bool {
+	return !opts.NoCache && (opts.FS == nil || opts.Writer != nil)
+}
+
+func (opts GenerateOptions) writer() Writer {
+	if opts.Writer == nil {
+		return OSWriter{}
+	}
+	return opts.Writer
+}
 
-	files, err := FindMarkdownFiles(logger, baseDir)
+func NewMarkdownGenerator(format string) (MarkdownGenerator, error) {
+	return NewMarkdownGeneratorWithLayout(format, Layout{})
+}
+
+// NewMarkdownGeneratorWithLayout returns a generator of the registered
+// format with the columns and group_by options set from layout. Only the
+// sections and table formats support a layout other than the zero Layout.
+func NewMarkdownGeneratorWithLayout(format string, layout Layout) (MarkdownGenerator, error) {
+	return NewFormatGenerator(format, layoutSettings(layout))
+}
+
+func GenerateMarkdownWithFormat(ctx context.Context, logger logr.Logger, baseDir, format string) error {
+	generator, err := NewMarkdownGenerator(format)
 	if err != nil {
-		return fmt.Errorf("error finding markdown files: %w", err)
+		return err
+	}
+
+	return GenerateMarkdown(ctx, logger, baseDir, generator)
+}
+
+func GenerateMarkdown(ctx context.Context, logger logr.Logger, baseDir string, generator MarkdownGenerator) error {
+	return GenerateMarkdownWithOptions(ctx, logger, GenerateOptions{BaseDir: baseDir}, generator)
+}
+
+// GenerateMarkdownWithOptions writes the index generator makes of the vault
+// described by opts. Scanning stops with the error of ctx once it is done.
+func GenerateMarkdownWithOptions(
+	ctx context.Context,
+	logger logr.Logger,
+	opts GenerateOptions,
+	generator MarkdownGenerator,
+) error {
+	logger.V(1).Info("Starting markdown generation",
+		"baseDir", opts.BaseDir, "noCache", opts.NoCache, "jobs", opts.Jobs)
+
+	filter, err := ParseFilter(opts.Filter)
+	if err != nil {
+		return fmt.Errorf("invalid filter: %w", err)
+	}
+	sortSpec, err := ParseSortSpec(opts.Sort)
+	if err != nil {
+		return fmt.Errorf("invalid sort: %w", err)
+	}
+
+	recipes, creators, err := collectRecipes(ctx, logger, opts, []string{opts.outputPath()})
+	if err != nil {
+		return err
+	}
+
+	recipes = selectRecipes(recipes, filter, sortSpec)
+	return writeIndex(ctx, opts.writer(), generator, GenerateInput{
+		Logger:     logger,
+		Recipes:    recipes,
+		Creators:   creators,
+		BaseDir:    opts.BaseDir,
+		OutputPath: opts.outputPath(),
+	})
+}
+
+// selectRecipes returns the recipes matching filter ordered by sortSpec,
+// leaving the input slice untouched.
+func selectRecipes(recipes []*RecipeInfo, filter RecipeFilter, sortSpec SortSpec) []*RecipeInfo {
+	selected := make([]*RecipeInfo, 0, len(recipes))
+	for _, recipe := range recipes {
+		if filter(recipe) {
+			selected = append(selected, recipe)
+		}
+	}
+	sortSpec.Sort(selected)
+	return selected
+}
+
+// writeIndex writes what generator makes of in to in.OutputPath.
+func writeIndex(ctx context.Context, w Writer, generator MarkdownGenerator, in GenerateInput) error {
+	logger, outputPath := in.Logger, in.OutputPath
+	content, err := generator.Generate(ctx, in)
+	if err != nil {
+		return fmt.Errorf("error generating markdown: %w", err)
+	}
+
+	if outputPath == StdoutPath {
+		if _, err := io.WriteString(os.Stdout, content); err != nil {
+			return fmt.Errorf("error writing to stdout: %w", err)
+		}
+		logger.V(1).Info("Markdown generation completed", "outputFile", "stdout")
+		return nil
+	}
+
+	if !isRawOutput(generator) {
+		existing, err := w.ReadFile(outputPath)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("error reading output file: %w", err)
+		}
+		content, err = spliceGenerated(outputPath, string(existing), content)
+		if err != nil {
+			return fmt.Errorf("refusing to write index: %w", err)
+		}
+	}
+
+	err = writeFile(logger, w, outputPath, []byte(content))
+	if err != nil {
+		return fmt.Errorf("error writing output file: %w", err)
+	}
+
+	logger.V(1).Info("Markdown generation completed", "outputFile", outputPath)
+	return nil
+}
+
+// ScanRecipes returns the recipes under opts.BaseDir matching opts.Filter
+// in the order of opts.Sort, together with the creators they reference
+// keyed by link target. Nothing but the cache is written.
+func ScanRecipes(
+	ctx context.Context,
+	logger logr.Logger,
+	opts GenerateOptions,
+) ([]*RecipeInfo, map[string]*CreatorInfo, error) {
+	filter, err := ParseFilter(opts.Filter)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid filter: %w", err)
+	}
+	sortSpec, err := ParseSortSpec(opts.Sort)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid sort: %w", err)
+	}
+
+	recipes, creators, err := collectRecipes(ctx, logger, opts, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return selectRecipes(recipes, filter, sortSpec), creators, nil
+}
+
+// collectRecipes finds and parses all recipes under opts.BaseDir together
+// with the creators they reference. The outputs that will be written are
+// not scanned.
+func collectRecipes(
+	ctx context.Context,
+	logger logr.Logger,
+	opts GenerateOptions,
+	outputs []string,
+) ([]*RecipeInfo, map[string]*CreatorInfo, error) {
+	baseDir := opts.BaseDir
+
+	unknown, err := unknownCreator(opts.MissingCreator, opts.PlaceholderImage)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := opts.Detection.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	var cache *Cache
+	if opts.cacheEnabled() {
+		cache = loadCache(logger, baseDir, opts.writer())
+	} else if !opts.NoCache {
+		logger.V(1).Info("Not caching a vault read from a file system without a writer")
+	}
+
+	files, err := findMarkdownFiles(logger, opts.vault(), WalkOptions{
+		Include: opts.Include,
+		Exclude: opts.Exclude,
+		Outputs: outputs,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("error finding markdown files: %w", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
 	}
 
 	logger.Info("Found markdown files", "count", len(files))
 
+	// The cache is not saved when the scan is cancelled, because it would
+	// drop the entries of the files that were not looked at.
+	parsedFiles, err := parseFiles(ctx, logger, cache, opts, files)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	var recipes []*RecipeInfo
+	var dropped []*FileError
 	creators := make(map[string]*CreatorInfo)
 	processedCount := 0
 	skippedCount := 0
+	unknownCount := 0
 
-	for _, file := range files {
+	for _, parsed := range parsedFiles {
+		file := parsed.path
 		logger.V(1).Info("Processing file", "file", file)
 
-		recipe, err := ParseRecipeFile(logger, file)
-		if err != nil {
-			logger.Error(err, "Failed to parse recipe file, skipping", "file", file)
-			skippedCount++
+		if parsed.err != nil {
+			dropped = append(dropped, &FileError{Path: file, Err: parsed.err})
 			continue
 		}
+		recipe := parsed.recipe
 		if recipe == nil {
 			logger.V(2).Info("Skipping non-recipe file", "file", file)
 			skippedCount++
 			continue
 		}
 
-		logger.V(1).Info("Parsed recipe file", "title", recipe.Title, "creator", recipe.Creator)
+		logger.V(1).Info("Parsed recipe file", "title", recipe.Title, "creators", creatorNames(recipe))
 
-		if recipe.Creator == "" {
-			logger.V(2).Info("Skipping recipe with no creator", "file", file)
-			skippedCount++
-			continue
+		found := 0
+		for i, link := range recipe.Creators {
+			if creator := parsed.creators[i]; creator != nil {
+				creators[link.Target] = creator
+				found++
+			}
 		}
 
-		if _, ok := creators[recipe.Creator]; !ok {
-			creator, err := ParseCreatorFile(logger, baseDir, recipe.Creator)
-			if err != nil {
-				logger.Error(
-					err,
-					"Failed to parse creator file, skipping",
-					"creator",
-					recipe.Creator,
-				)
-				skippedCount++
+		switch {
+		case found == 0:
+			reason := parsed.creatorErr
+			if len(recipe.Creators) == 0 {
+				reason = ErrNoCreator
+			}
+			if unknown == nil {
+				dropped = append(dropped, &FileError{Path: file, Err: reason})
 				continue
 			}
-			creators[recipe.Creator] = creator
+			logger.Info("Listing recipe under "+UnknownCreatorName, "file", file, "reason", reason.Error())
+			creators[unknownCreatorKey] = unknown
+			unknownCount++
+		case parsed.creatorErr != nil:
+			logger.Error(parsed.creatorErr, "Some creators were not found, listing the recipe with the others",
+				"file", file)
 		}
 
 		recipe.Slug = slug.Make(recipe.Title)
@@ -91,39 +338,62 @@ func GenerateMarkdown(logger logr.Logger, baseDir string, generator MarkdownGene
 		processedCount++
 	}
 
+	for _, f := range dropped {
+		logger.Error(f.Err, "Dropped file from the index", "file", f.Path)
+	}
+
 	logger.Info("Markdown generation summary",
 		"totalFiles", len(files),
 		"processedFiles", processedCount,
 		"skippedFiles", skippedCount,
+		"droppedFiles", len(dropped),
+		"unknownCreatorFiles", unknownCount,
 		"recipeCount", len(recipes))
 
-	content, err := generator.Generate(logger, recipes, creators)
-	if err != nil {
-		return fmt.Errorf("error generating markdown: %w", err)
+	if err := cache.Save(logger); err != nil {
+		logger.Error(err, "Failed to save cache")
 	}
 
-	toc := generateTOC(recipes)
-	content = "\n\n\n\n\n\n" + "# TOC\n" + toc + "\n" + content
-
-	outputPath := filepath.Join(baseDir, "recipeindex.md")
-	err = WriteFile(logger, outputPath, []byte(content))
-	if err != nil {
-		return fmt.Errorf("error writing output file: %w", err)
+	if opts.Strict && len(dropped) > 0 {
+		return nil, nil, &ScanError{Files: dropped}
 	}
 
-	logger.V(1).Info("Markdown generation completed", "outputFile", outputPath)
-	return nil
+	return recipes, creators, nil
 }
 
-func generateTOC(recipes []*RecipeInfo) string {
+// withTOC prefixes content with the table of contents heading used by the
+// built-in generators.
+func withTOC(groups []recipeGroup, content string) string {
+	return "# TOC\n" + generateTOC(groups) + "\n" + content
+}
+
+// generateTOC lists the recipes of groups, nested under a link to their
+// group when grouped. A recipe listed in several groups only carries its
+// block reference the first time.
+func generateTOC(groups []recipeGroup) string {
 	var toc []string
-	for _, recipe := range recipes {
-		toc = append(toc, fmt.Sprintf("- [[#%s|%s]] ^%s", recipe.Title, recipe.Title, recipe.Slug))
-	}
+	listed := make(map[*RecipeInfo]bool)
+	for _, group := range groups {
+		indent := ""
+		if group.Name != "" {
+			toc = append(toc, fmt.Sprintf("- [[#%s|%s]]", group.Name, group.Name))
+			indent = "  "
+		}
 
-	sort.Slice(toc, func(i, j int) bool {
-		return strings.ToLower(toc[i]) < strings.ToLower(toc[j])
-	})
+		for _, recipe := range group.Recipes {
+			entry := fmt.Sprintf("%s- [[#%s|%s]]", indent, recipe.Title, recipe.Title)
+			if !listed[recipe] {
+				entry += " ^" + recipe.Slug
+				listed[recipe] = true
+			}
+			toc = append(toc, entry)
+			for _, alias := range recipe.Aliases {
+				if !strings.EqualFold(alias, recipe.Title) {
+					toc = append(toc, fmt.Sprintf("%s  - [[#%s|%s]]", indent, recipe.Title, alias))
+				}
+			}
+		}
+	}
 
 	return strings.Join(toc, "\n")
 }
@@ -0,0 +1,385 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+)
+
+func writeTestVault(t *testing.T, recipeCount, creatorCount int) string {
+	t.Helper()
+	baseDir := t.TempDir()
+
+	write := func(name, content string) {
+		path := filepath.Join(baseDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i := range creatorCount {
+		write(fmt.Sprintf("Creator %d.md", i), fmt.Sprintf("---\npic: creator-%d.jpg\n---\n", i))
+	}
+	for i := range recipeCount {
+		write(
+			fmt.Sprintf("recipes/Recipe %03d.md", i),
+			fmt.Sprintf(
+				"---\nfiletype: recipe\npic: https://example.com/%d.jpg\ncreator: \"[[Creator %d]]\"\n---\n",
+				i, i%creatorCount,
+			),
+		)
+	}
+	write("notes/Not a recipe.md", "---\nfiletype: note\n---\n")
+
+	return baseDir
+}
+
+func TestGenerateMarkdown_JobsProduceIdenticalOutput(t *testing.T) {
+	logger := testr.New(t)
+	baseDir := writeTestVault(t, 40, 3)
+	outputPath := filepath.Join(baseDir, IndexFileName)
+
+	var outputs []string
+	for _, jobs := range []int{1, 8} {
+		opts := GenerateOptions{BaseDir: baseDir, NoCache: true, Jobs: jobs}
+		if err := GenerateMarkdownWithOptions(t.Context(), logger, opts, NewTableMarkdownGenerator()); err != nil {
+			t.Fatalf("Unexpected error with %d jobs: %v", jobs, err)
+		}
+		content, err := os.ReadFile(outputPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		outputs = append(outputs, string(content))
+		if err := os.Remove(outputPath); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if outputs[0] != outputs[1] {
+		t.Errorf("Output differs between 1 and 8 jobs:\n%s\n---\n%s", outputs[0], outputs[1])
+	}
+}
+
+func TestGenerateMarkdown_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	baseDir := writeTestVault(t, 3, 1)
+	opts := GenerateOptions{BaseDir: baseDir, Jobs: 1}
+	err := GenerateMarkdownWithOptions(ctx, testr.New(t), opts, NewTableMarkdownGenerator())
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(baseDir, IndexFileName)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Did not expect a cancelled run to write the index, stat returned %v", err)
+	}
+}
+
+func TestGenerateMarkdown_StrictFailsOnDroppedRecipes(t *testing.T) {
+	logger := testr.New(t)
+	baseDir := writeTestVault(t, 3, 1)
+	outputPath := filepath.Join(baseDir, IndexFileName)
+
+	broken := map[string]string{
+		"recipes/No Creator.md": "---\nfiletype: recipe\n---\n",
+		"recipes/Orphan.md":     "---\nfiletype: recipe\ncreator: \"[[Nobody]]\"\n---\n",
+	}
+	for name, content := range broken {
+		if err := os.WriteFile(filepath.Join(baseDir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := GenerateOptions{BaseDir: baseDir, NoCache: true}
+	if err := GenerateMarkdownWithOptions(t.Context(), logger, opts, NewTableMarkdownGenerator()); err != nil {
+		t.Fatalf("Unexpected error without strict: %v", err)
+	}
+	if err := os.Remove(outputPath); err != nil {
+		t.Fatalf("Expected index to be written without strict: %v", err)
+	}
+
+	opts.Strict = true
+	err := GenerateMarkdownWithOptions(t.Context(), logger, opts, NewTableMarkdownGenerator())
+
+	var scanErr *ScanError
+	if !errors.As(err, &scanErr) {
+		t.Fatalf("Expected *ScanError, got %v", err)
+	}
+	if len(scanErr.Files) != 2 {
+		t.Fatalf("Expected 2 dropped files, got %d: %v", len(scanErr.Files), scanErr)
+	}
+	if !errors.Is(err, ErrNoCreator) {
+		t.Errorf("Expected ErrNoCreator in %v", err)
+	}
+	var creatorErr *CreatorError
+	if got := scanErr.Files[1]; !errors.As(got, &creatorErr) || creatorErr.Creator != "Nobody" ||
+		!errors.Is(got, ErrNoteNotFound) {
+		t.Errorf("Unexpected error for missing creator: %v", got)
+	}
+	if _, err := os.Stat(outputPath); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected no index to be written in strict mode, stat returned %v", err)
+	}
+}
+
+func TestGenerateMarkdown_StrictFailsOnInvalidFrontmatter(t *testing.T) {
+	logger := testr.New(t)
+	baseDir := writeTestVault(t, 2, 1)
+	broken := filepath.Join(baseDir, "recipes", "Broken.md")
+	content := "---\nfiletype: recipe\ncreator: \"[[Creator 0]]\"\ntags: [vegan\n---\n"
+	if err := os.WriteFile(broken, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := GenerateOptions{BaseDir: baseDir, NoCache: true, Strict: true}
+	err := GenerateMarkdownWithOptions(t.Context(), logger, opts, NewTableMarkdownGenerator())
+
+	var scanErr *ScanError
+	if !errors.As(err, &scanErr) {
+		t.Fatalf("Expected *ScanError, got %v", err)
+	}
+	if len(scanErr.Files) != 1 || scanErr.Files[0].Path != broken {
+		t.Errorf("Expected only %s to be dropped, got %v", broken, scanErr)
+	}
+}
+
+func TestGenerateMarkdown_MultipleCreators(t *testing.T) {
+	logger := testr.New(t)
+	baseDir := writeTestVault(t, 1, 2)
+	outputPath := filepath.Join(baseDir, IndexFileName)
+
+	recipe := "---\nfiletype: recipe\npic: shared.jpg\ncreator:\n  - \"[[Creator 1]]\"\n  - [[Creator 0]]\n  - \"[[Ghost]]\"\n---\n"
+	if err := os.WriteFile(filepath.Join(baseDir, "recipes", "Shared.md"), []byte(recipe), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := GenerateOptions{BaseDir: baseDir, NoCache: true, Strict: true}
+	if err := GenerateMarkdownWithOptions(t.Context(), logger, opts, NewTableMarkdownGenerator()); err != nil {
+		t.Fatalf("Expected recipe with a missing creator to be kept, got %v", err)
+	}
+
+	content, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedRow := "| ![[shared.jpg]] [[Shared]] [[#^shared|toc]]** | " +
+		"![[creator-1.jpg]] [[Creator 1]]<br>![[creator-0.jpg]] [[Creator 0]] |"
+	if !strings.Contains(string(content), expectedRow) {
+		t.Errorf("Expected row %s not found. Got:\n%s", expectedRow, content)
+	}
+}
+
+func TestGenerateMarkdown_MissingCreatorPolicy(t *testing.T) {
+	recipes := map[string]string{
+		"Ghostly.md":   "---\nfiletype: recipe\npic: ghostly.jpg\ncreator: \"[[Ghost]]\"\n---\n",
+		"Anonymous.md": "---\nfiletype: recipe\npic: anonymous.jpg\n---\n",
+	}
+
+	tests := []struct {
+		name        string
+		opts        GenerateOptions
+		wantErr     bool
+		wantRows    []string
+		notExpected string
+	}{
+		{
+			name:        "drop",
+			opts:        GenerateOptions{MissingCreator: MissingCreatorDrop},
+			notExpected: UnknownCreatorName,
+		},
+		{
+			name: "include unknown",
+			opts: GenerateOptions{MissingCreator: MissingCreatorIncludeUnknown, Strict: true},
+			wantRows: []string{
+				"| ![[anonymous.jpg]] [[Anonymous]] [[#^anonymous|toc]]** | Unknown creator |",
+				"| ![[ghostly.jpg]] [[Ghostly]] [[#^ghostly|toc]]** | Unknown creator |",
+			},
+		},
+		{
+			name: "placeholder",
+			opts: GenerateOptions{MissingCreator: MissingCreatorPlaceholder, PlaceholderImage: "nobody.png"},
+			wantRows: []string{
+				"| ![[ghostly.jpg]] [[Ghostly]] [[#^ghostly|toc]]** | ![[nobody.png]] Unknown creator |",
+			},
+		},
+		{
+			name:    "placeholder without image",
+			opts:    GenerateOptions{MissingCreator: MissingCreatorPlaceholder},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := testr.New(t)
+			baseDir := writeTestVault(t, 1, 1)
+			for name, recipe := range recipes {
+				if err := os.WriteFile(filepath.Join(baseDir, "recipes", name), []byte(recipe), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			opts := tt.opts
+			opts.BaseDir = baseDir
+			opts.NoCache = true
+			err := GenerateMarkdownWithOptions(t.Context(), logger, opts, NewTableMarkdownGenerator())
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GenerateMarkdownWithOptions failed: %v", err)
+			}
+
+			content, err := os.ReadFile(filepath.Join(baseDir, IndexFileName))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, row := range tt.wantRows {
+				if !strings.Contains(string(content), row) {
+					t.Errorf("Expected row %s not found. Got:\n%s", row, content)
+				}
+			}
+			if tt.notExpected != "" && strings.Contains(string(content), tt.notExpected) {
+				t.Errorf("Did not expect %q in the index. Got:\n%s", tt.notExpected, content)
+			}
+		})
+	}
+}
+
+func TestParseMissingCreatorPolicy(t *testing.T) {
+	for input, want := range map[string]MissingCreatorPolicy{
+		"":                MissingCreatorDrop,
+		"drop":            MissingCreatorDrop,
+		"Include-Unknown": MissingCreatorIncludeUnknown,
+		" placeholder ":   MissingCreatorPlaceholder,
+	} {
+		got, err := ParseMissingCreatorPolicy(input)
+		if err != nil || got != want {
+			t.Errorf("ParseMissingCreatorPolicy(%q) = %q, %v; want %q", input, got, err, want)
+		}
+	}
+
+	if _, err := ParseMissingCreatorPolicy("keep"); err == nil {
+		t.Error("Expected an error for an unknown policy")
+	}
+}
+
+func TestParseRecipeContent_Title(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "frontmatter title",
+			content: "---\nfiletype: recipe\ntitle: Apple Pie\n---\n# Heading\n",
+			want:    "Apple Pie",
+		},
+		{
+			name:    "first heading",
+			content: "---\nfiletype: recipe\n---\nIntro\n\n## Notes\n\n# Grandma's *Apple* [Pie](https://example.com) `v2`\n\n# Later\n",
+			want:    "Grandma's Apple Pie v2",
+		},
+		{
+			name:    "empty title falls back to heading",
+			content: "---\nfiletype: recipe\ntitle: \"\"\n---\n# Apple Pie\n",
+			want:    "Apple Pie",
+		},
+		{
+			name:    "file name",
+			content: "---\nfiletype: recipe\n---\n## Not a title\n",
+			want:    "2023-05-apple-pie-v2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recipe, err := parseRecipeContent(testr.New(t), "recipes/2023-05-apple-pie-v2.md", []byte(tt.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if recipe.Title != tt.want {
+				t.Errorf("Expected title %q, got %q", tt.want, recipe.Title)
+			}
+			if recipe.Name != "2023-05-apple-pie-v2" {
+				t.Errorf("Expected name to be the file name, got %q", recipe.Name)
+			}
+		})
+	}
+}
+
+func TestGenerateMarkdown_TitleAndAliases(t *testing.T) {
+	logger := testr.New(t)
+	baseDir := writeTestVault(t, 1, 1)
+
+	recipe := "---\nfiletype: recipe\npic: pie.jpg\ntitle: Apple Pie\naliases: [Grandma's Pie, apple pie]\ncreator: \"[[Creator 0]]\"\n---\n"
+	if err := os.WriteFile(filepath.Join(baseDir, "recipes", "2023-05-apple-pie-v2.md"), []byte(recipe), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := GenerateOptions{BaseDir: baseDir, NoCache: true, Filter: "title=grandma's pie"}
+	if err := GenerateMarkdownWithOptions(t.Context(), logger, opts, NewSectionMarkdownGenerator()); err != nil {
+		t.Fatalf("GenerateMarkdownWithOptions failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(baseDir, IndexFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"- [[#Apple Pie|Apple Pie]] ^apple-pie\n  - [[#Apple Pie|Grandma's Pie]]\n",
+		"## Apple Pie\n",
+		"| [[2023-05-apple-pie-v2\\|Apple Pie]] | [[Creator 0]] |",
+	} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("Expected %q in the index. Got:\n%s", want, content)
+		}
+	}
+	if strings.Contains(string(content), "|apple pie]]") {
+		t.Errorf("Did not expect an alias that only differs in case in the TOC. Got:\n%s", content)
+	}
+}
+
+func TestParseCreatorLinks(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  []string
+	}{
+		{name: "string", value: "[[Jane|J]]", want: []string{"Jane"}},
+		{name: "unquoted wikilink", value: []interface{}{[]interface{}{"Jane"}}, want: []string{"Jane"}},
+		{name: "list", value: []interface{}{"Sam", "[[Jane]]"}, want: []string{"Sam", "Jane"}},
+		{
+			name:  "list of unquoted wikilinks",
+			value: []interface{}{[]interface{}{[]interface{}{"Sam"}}, []interface{}{[]interface{}{"Jane"}}},
+			want:  []string{"Sam", "Jane"},
+		},
+		{name: "duplicates", value: []interface{}{"[[Jane]]", "jane", ""}, want: []string{"Jane"}},
+		{name: "missing", value: nil, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, link := range parseCreatorLinks(tt.value) {
+				got = append(got, link.Target)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
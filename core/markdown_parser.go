@@ -5,26 +5,52 @@ import (
 	"net/url"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/yuin/goldmark"
 	meta "github.com/yuin/goldmark-meta"
+	"github.com/yuin/goldmark/ast"
 	"github.com/yuin/goldmark/parser"
 	"github.com/yuin/goldmark/text"
 )
 
 type RecipeInfo struct {
-	Title         string
-	ImageURL      string
-	Creator       string
+	Path string
+	// Name is the file name of the note without the .md extension, which
+	// is what wikilinks to the recipe use.
+	Name string
+	// Title is the title frontmatter field, else the first level one
+	// heading of the note, else Name.
+	Title string
+	// Aliases are the other names the note is known by, from the aliases
+	// frontmatter field.
+	Aliases  []string
+	ImageURL string
+	// Creators are the creator links as written, in the order they are
+	// listed and without duplicates. Their targets are the keys of the
+	// creators map passed to generators.
+	Creators      []Wikilink
 	IsRemoteImage bool
 	Slug          string
+	// RecipeMetadata holds the well-known optional fields of the
+	// frontmatter, which is also kept in full in Frontmatter.
+	RecipeMetadata
+	Frontmatter map[string]interface{}
+	// ModTime is when the note was last modified.
+	ModTime time.Time
 }
 
 type CreatorInfo struct {
-	Name          string
+	Path string
+	Name string
+	// Link is the shortest link target that resolves to the creator note in
+	// the vault. It is empty when the creator was not resolved through the
+	// vault, in which case Name is used.
+	Link          string
 	ImageURL      string
 	IsRemoteImage bool
+	Frontmatter   map[string]interface{}
 }
 
 func ParseRecipeFile(logger logr.Logger, path string) (*RecipeInfo, error) {
@@ -33,47 +59,205 @@ func ParseRecipeFile(logger logr.Logger, path string) (*RecipeInfo, error) {
 		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
 
+	return parseRecipeContent(logger, path, content)
+}
+
+func parseRecipeContent(logger logr.Logger, path string, content []byte) (*RecipeInfo, error) {
+	note, err := parseNoteContent(logger, noteDetector{}, path, content)
+	return note.recipe, err
+}
+
+// parsedNote is what parsing tells about any note.
+type parsedNote struct {
+	// recipe is nil unless the note is a recipe.
+	recipe *RecipeInfo
+	// aliases are the other names the note can be linked to by.
+	aliases []string
+	// creator is set when creator links may resolve to the note.
+	creator bool
+}
+
+// parseNoteContent parses any note, using detector to decide whether it is
+// a recipe and whether it can be a creator.
+func parseNoteContent(logger logr.Logger, detector noteDetector, path string, content []byte) (parsedNote, error) {
 	markdown := goldmark.New(goldmark.WithExtensions(meta.Meta))
 	context := parser.NewContext()
-	markdown.Parser().Parse(text.NewReader(content), parser.WithContext(context))
+	doc := markdown.Parser().Parse(text.NewReader(content), parser.WithContext(context))
 
-	metaData := meta.Get(context)
+	metaData, err := meta.TryGet(context)
+	if err != nil {
+		return parsedNote{}, fmt.Errorf("invalid frontmatter: %w", err)
+	}
 	logger.V(2).Info("Parsed frontmatter", "file", path, "metadata", metaData)
 
-	fileType, ok := metaData["filetype"].(string)
-	if !ok || fileType != "recipe" {
-		logger.V(2).Info("File is not a recipe", "file", path, "filetype", fileType)
-		return nil, nil
+	frontmatter := normalizeFrontmatter(metaData)
+	facts := detector.facts(path, frontmatter, doc, content)
+	note := parsedNote{
+		aliases: frontmatterAliases(frontmatter),
+		creator: detector.isCreator(facts),
+	}
+
+	if !detector.isRecipe(facts) {
+		logger.V(2).Info("File is not a recipe", "file", path)
+		return note, nil
 	}
 
 	pic, _ := metaData["pic"].(string)
-	creator, _ := metaData["creator"].(string)
+	creators := parseCreatorLinks(metaData["creator"])
 
 	if pic == "" {
 		logger.V(2).Info("Recipe file has no 'pic' field", "file", path)
 	}
 
-	if creator == "" {
+	if len(creators) == 0 {
 		logger.V(2).Info("Recipe file has no 'creator' field", "file", path)
 	}
 
 	isRemoteImage := isRemoteURL(pic)
 
-	return &RecipeInfo{
-		Title:         strings.TrimSuffix(filepath.Base(path), ".md"),
-		ImageURL:      pic,
-		Creator:       strings.Trim(creator, "[]"),
-		IsRemoteImage: isRemoteImage,
-	}, nil
+	name := noteName(path)
+	title := recipeTitle(name, frontmatter, firstHeading(doc, content))
+	logger.V(2).Info("Determined recipe title", "file", path, "title", title)
+
+	metadata, errs := parseRecipeMetadata(frontmatter)
+	for _, err := range errs {
+		logger.Info("Ignoring invalid recipe metadata", "file", path, "reason", err.Error())
+	}
+
+	note.recipe = &RecipeInfo{
+		Path:           path,
+		Name:           name,
+		Title:          title,
+		Aliases:        note.aliases,
+		ImageURL:       pic,
+		Creators:       creators,
+		IsRemoteImage:  isRemoteImage,
+		RecipeMetadata: metadata,
+		Frontmatter:    frontmatter,
+	}
+	return note, nil
+}
+
+// recipeTitle picks the title of a recipe named name: the title field of its
+// frontmatter, else heading, else the name itself.
+func recipeTitle(name string, frontmatter map[string]interface{}, heading string) string {
+	if title, ok := frontmatter["title"].(string); ok && strings.TrimSpace(title) != "" {
+		return strings.TrimSpace(title)
+	}
+	if heading != "" {
+		return heading
+	}
+	return name
+}
+
+// firstHeading returns the plain text of the first level one heading of doc,
+// or "" when there is none.
+func firstHeading(doc ast.Node, source []byte) string {
+	var heading string
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if h, ok := n.(*ast.Heading); ok && entering && h.Level == 1 {
+			heading = strings.TrimSpace(inlineText(h, source))
+			return ast.WalkStop, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	return heading
+}
+
+// parseMarkdown parses content, frontmatter included, into a document.
+func parseMarkdown(content []byte) ast.Node {
+	markdown := goldmark.New(goldmark.WithExtensions(meta.Meta))
+	return markdown.Parser().Parse(text.NewReader(content))
+}
+
+// inlineText concatenates the text of the inline children of n, dropping
+// emphasis and link markup.
+func inlineText(n ast.Node, source []byte) string {
+	var b strings.Builder
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		switch c := c.(type) {
+		case *ast.Text:
+			b.Write(c.Value(source))
+			if c.SoftLineBreak() || c.HardLineBreak() {
+				b.WriteString(" ")
+			}
+		case *ast.String:
+			b.Write(c.Value)
+		case *ast.RawHTML, *ast.Image:
+		default:
+			b.WriteString(inlineText(c, source))
+		}
+	}
+	return b.String()
+}
+
+// parseCreatorLinks reads the creator field of a recipe, which is a single
+// name or wikilink or a list of them. An unquoted [[Name]] is a nested list
+// in YAML and is read as the link it was meant to be.
+func parseCreatorLinks(value interface{}) []Wikilink {
+	var values []interface{}
+	if list, ok := value.([]interface{}); ok && !isUnquotedWikilink(list) {
+		values = list
+	} else {
+		values = []interface{}{value}
+	}
+
+	var links []Wikilink
+	seen := make(map[string]bool)
+	for _, v := range values {
+		var link Wikilink
+		switch v := v.(type) {
+		case string:
+			link = ParseWikilink(v)
+		case []interface{}:
+			if !isUnquotedWikilink(v) {
+				continue
+			}
+			link = Wikilink{Target: strings.TrimSpace(fmt.Sprint(v[0].([]interface{})[0]))}
+		default:
+			continue
+		}
+
+		key := strings.ToLower(link.Target)
+		if link.Target == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		links = append(links, link)
+	}
+	return links
+}
+
+func isUnquotedWikilink(list []interface{}) bool {
+	if len(list) != 1 {
+		return false
+	}
+	inner, ok := list[0].([]interface{})
+	if !ok || len(inner) != 1 {
+		return false
+	}
+	_, ok = inner[0].(string)
+	return ok
 }
 
 func ParseCreatorFile(logger logr.Logger, baseDir, creatorName string) (*CreatorInfo, error) {
-	path := filepath.Join(baseDir, creatorName+".md")
-	content, err := ReadFile(logger, path)
+	return parseCreatorPath(logger, newVault(baseDir, nil), creatorPath(baseDir, creatorName), creatorName)
+}
+
+func parseCreatorPath(logger logr.Logger, v vault, path, creatorName string) (*CreatorInfo, error) {
+	content, err := v.readFile(logger, path)
 	if err != nil {
 		return nil, err
 	}
 
+	return parseCreatorContent(logger, path, creatorName, content), nil
+}
+
+func creatorPath(baseDir, creatorName string) string {
+	return filepath.Join(baseDir, creatorName+".md")
+}
+
+func parseCreatorContent(logger logr.Logger, path, creatorName string, content []byte) *CreatorInfo {
 	markdown := goldmark.New(goldmark.WithExtensions(meta.Meta))
 	context := parser.NewContext()
 	markdown.Parser().Parse(text.NewReader(content), parser.WithContext(context))
@@ -85,10 +269,12 @@ func ParseCreatorFile(logger logr.Logger, baseDir, creatorName string) (*Creator
 	isRemoteImage := isRemoteURL(pic)
 
 	return &CreatorInfo{
+		Path:          path,
 		Name:          creatorName,
 		ImageURL:      pic,
 		IsRemoteImage: isRemoteImage,
-	}, nil
+		Frontmatter:   normalizeFrontmatter(metaData),
+	}
 }
 
 func isRemoteURL(urlString string) bool {
@@ -0,0 +1,88 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	BeginMarker = "<!-- wholeoverride:begin -->"
+	EndMarker   = "<!-- wholeoverride:end -->"
+
+	markerPrefix = "wholeoverride:"
+	legacyHeader = "\n\n\n\n\n\n# TOC\n"
+)
+
+// MarkerError reports index markers that are missing, duplicated or out of
+// order. The index is not written when it occurs.
+type MarkerError struct {
+	Path   string
+	Line   int
+	Reason string
+}
+
+func (e *MarkerError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: malformed index markers: %s", e.Path, e.Line, e.Reason)
+	}
+	return fmt.Sprintf("%s: malformed index markers: %s", e.Path, e.Reason)
+}
+
+// rawOutputGenerator is implemented by generators whose output is not
+// markdown. Their output replaces the whole file instead of being spliced
+// between the index markers.
+type rawOutputGenerator interface {
+	RawOutput() bool
+}
+
+func isRawOutput(generator MarkdownGenerator) bool {
+	raw, ok := generator.(rawOutputGenerator)
+	return ok && raw.RawOutput()
+}
+
+func markedRegion(generated string) string {
+	return BeginMarker + "\n" + strings.TrimRight(generated, "\n") + "\n" + EndMarker + "\n"
+}
+
+// spliceGenerated replaces the region between the index markers in existing
+// with generated and leaves everything around it untouched. When existing
+// has no markers the region is created: files written by versions without
+// markers are replaced, anything else keeps its content and gets the region
+// appended.
+func spliceGenerated(path, existing, generated string) (string, error) {
+	lines := strings.SplitAfter(existing, "\n")
+	begin, end := -1, -1
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == BeginMarker:
+			if begin >= 0 {
+				return "", &MarkerError{path, i + 1, fmt.Sprintf("duplicate begin marker, first one is on line %d", begin+1)}
+			}
+			begin = i
+		case trimmed == EndMarker:
+			if end >= 0 {
+				return "", &MarkerError{path, i + 1, fmt.Sprintf("duplicate end marker, first one is on line %d", end+1)}
+			}
+			if begin < 0 {
+				return "", &MarkerError{path, i + 1, "end marker before begin marker"}
+			}
+			end = i
+		case strings.Contains(trimmed, markerPrefix+"begin") || strings.Contains(trimmed, markerPrefix+"end"):
+			return "", &MarkerError{path, i + 1, fmt.Sprintf(
+				"marker must be on a line of its own and read exactly %q or %q", BeginMarker, EndMarker)}
+		}
+	}
+
+	switch {
+	case begin >= 0 && end < 0:
+		return "", &MarkerError{path, begin + 1, "begin marker has no matching end marker"}
+	case begin >= 0:
+		return strings.Join(lines[:begin], "") + markedRegion(generated) + strings.Join(lines[end+1:], ""), nil
+	case strings.TrimSpace(existing) == "" || strings.HasPrefix(existing, legacyHeader):
+		return markedRegion(generated), nil
+	default:
+		return strings.TrimRight(existing, "\n") + "\n\n" + markedRegion(generated), nil
+	}
+}
@@ -0,0 +1,81 @@
+package core
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSpliceGenerated(t *testing.T) {
+	region := BeginMarker + "\n# TOC\nnew\n" + EndMarker + "\n"
+
+	tests := []struct {
+		name     string
+		existing string
+		want     string
+		wantLine int
+	}{
+		{
+			name:     "new file",
+			existing: "",
+			want:     region,
+		},
+		{
+			name:     "legacy output without markers",
+			existing: "\n\n\n\n\n\n# TOC\n- old\n",
+			want:     region,
+		},
+		{
+			name:     "hand-written file without markers",
+			existing: "---\ncssclass: wide\n---\nMy recipes\n\n",
+			want:     "---\ncssclass: wide\n---\nMy recipes\n\n" + region,
+		},
+		{
+			name: "replaces region and keeps surrounding content",
+			existing: "---\ntags: [index]\n---\nIntro\n" + BeginMarker + "\nold\nstuff\n" + EndMarker +
+				"\n```dataview\nLIST\n```\n",
+			want: "---\ntags: [index]\n---\nIntro\n" + region + "```dataview\nLIST\n```\n",
+		},
+		{
+			name:     "begin without end",
+			existing: "Intro\n" + BeginMarker + "\nold\n",
+			wantLine: 2,
+		},
+		{
+			name:     "end before begin",
+			existing: EndMarker + "\n" + BeginMarker + "\n",
+			wantLine: 1,
+		},
+		{
+			name:     "duplicate begin",
+			existing: BeginMarker + "\n" + BeginMarker + "\n" + EndMarker + "\n",
+			wantLine: 2,
+		},
+		{
+			name:     "marker not on its own line",
+			existing: "text " + BeginMarker + "\n" + EndMarker + "\n",
+			wantLine: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := spliceGenerated("index.md", tt.existing, "# TOC\nnew\n\n")
+			if tt.wantLine > 0 {
+				var markerErr *MarkerError
+				if !errors.As(err, &markerErr) {
+					t.Fatalf("Expected MarkerError, got %v", err)
+				}
+				if markerErr.Line != tt.wantLine {
+					t.Errorf("Expected error on line %d, got %v", tt.wantLine, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Unexpected result.\nGot:\n%q\nWant:\n%q", got, tt.want)
+			}
+		})
+	}
+}
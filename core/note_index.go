@@ -0,0 +1,184 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ErrNoteNotFound is returned when a link does not resolve to any note.
+var ErrNoteNotFound = errors.New("no note with this name or alias")
+
+// AmbiguousLinkError is returned when a link matches more than one note.
+type AmbiguousLinkError struct {
+	Link       string
+	Candidates []string
+}
+
+func (e *AmbiguousLinkError) Error() string {
+	return fmt.Sprintf("link %q is ambiguous, it matches %s; qualify it with a folder or set creators_dir",
+		e.Link, strings.Join(e.Candidates, ", "))
+}
+
+// noteIndex resolves link targets to notes the way Obsidian does with its
+// "shortest path when possible" link format: a link names a note by its file
+// name, or by as much of its path as is needed to tell it apart from other
+// notes of the same name. Notes can also be reached through the aliases in
+// their frontmatter when no file name matches. Matching is case-insensitive.
+// Links only resolve to notes that were added as creators, while link
+// targets are computed against every note.
+type noteIndex struct {
+	baseDir string
+	// dir, when set, limits resolution to notes below it, relative to
+	// baseDir.
+	dir string
+
+	byName  map[string][]*indexedNote
+	byAlias map[string][]*indexedNote
+}
+
+type indexedNote struct {
+	path string
+	// key is the lowercased path relative to baseDir with forward slashes
+	// and without the .md extension.
+	key string
+	// creator is set when links may resolve to the note.
+	creator bool
+}
+
+func (n *indexedNote) inDir(dir string) bool {
+	return dir == "" || strings.HasPrefix(n.key, dir+"/")
+}
+
+func newNoteIndex(baseDir, dir string) *noteIndex {
+	return &noteIndex{
+		baseDir: baseDir,
+		dir:     linkKey(dir),
+		byName:  make(map[string][]*indexedNote),
+		byAlias: make(map[string][]*indexedNote),
+	}
+}
+
+func (idx *noteIndex) add(notePath string, aliases []string, creator bool) {
+	rel, err := filepath.Rel(idx.baseDir, notePath)
+	if err != nil {
+		rel = notePath
+	}
+
+	note := &indexedNote{path: notePath, key: linkKey(rel), creator: creator}
+	name := path.Base(note.key)
+	idx.byName[name] = append(idx.byName[name], note)
+	seen := make(map[string]bool, len(aliases))
+	for _, alias := range aliases {
+		key := linkKey(alias)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		idx.byAlias[key] = append(idx.byAlias[key], note)
+	}
+}
+
+// resolve returns the path of the note link refers to. A link that spells
+// out a note's full path always resolves to it; otherwise the link must
+// match exactly one note by trailing path or, failing that, by alias.
+func (idx *noteIndex) resolve(link string) (string, error) {
+	matches := idx.lookup(linkKey(link), func(note *indexedNote) bool {
+		return note.creator && note.inDir(idx.dir)
+	})
+
+	switch len(matches) {
+	case 0:
+		return "", ErrNoteNotFound
+	case 1:
+		return matches[0].path, nil
+	default:
+		candidates := make([]string, len(matches))
+		for i, note := range matches {
+			candidates[i] = displayPath(note.path)
+		}
+		sort.Strings(candidates)
+		return "", &AmbiguousLinkError{Link: link, Candidates: candidates}
+	}
+}
+
+// lookup returns the notes key refers to among those accepted, or among
+// every note when accept is nil.
+func (idx *noteIndex) lookup(key string, accept func(*indexedNote) bool) []*indexedNote {
+	if accept == nil {
+		accept = func(*indexedNote) bool { return true }
+	}
+
+	var matches []*indexedNote
+	for _, note := range idx.byName[path.Base(key)] {
+		if !accept(note) {
+			continue
+		}
+		if note.key == key {
+			return []*indexedNote{note}
+		}
+		if strings.HasSuffix(note.key, "/"+key) {
+			matches = append(matches, note)
+		}
+	}
+	if len(matches) > 0 {
+		return matches
+	}
+
+	for _, note := range idx.byAlias[key] {
+		if accept(note) {
+			matches = append(matches, note)
+		}
+	}
+	return matches
+}
+
+// link returns the shortest link target that Obsidian resolves to the note
+// at notePath, taking every note in the vault into account.
+func (idx *noteIndex) link(notePath string) string {
+	rel, err := filepath.Rel(idx.baseDir, notePath)
+	if err != nil {
+		rel = notePath
+	}
+	parts := strings.Split(strings.TrimSuffix(filepath.ToSlash(rel), ".md"), "/")
+
+	for i := len(parts) - 1; i > 0; i-- {
+		candidate := strings.Join(parts[i:], "/")
+		if matches := idx.lookup(linkKey(candidate), nil); len(matches) == 1 && matches[0].path == notePath {
+			return candidate
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+func linkKey(link string) string {
+	key := strings.ToLower(filepath.ToSlash(strings.TrimSpace(link)))
+	key = strings.TrimSuffix(key, ".md")
+	key = strings.Trim(path.Clean("/"+key), "/")
+	return key
+}
+
+// frontmatterAliases returns the aliases declared in frontmatter, which may
+// be a single string or a list.
+func frontmatterAliases(frontmatter map[string]interface{}) []string {
+	switch v := frontmatter["aliases"].(type) {
+	case string:
+		if v == "" {
+			return nil
+		}
+		return []string{v}
+	case []interface{}:
+		aliases := make([]string, 0, len(v))
+		for _, item := range v {
+			if s := strings.TrimSpace(fmt.Sprint(item)); s != "" && item != nil {
+				aliases = append(aliases, s)
+			}
+		}
+		return aliases
+	default:
+		return nil
+	}
+}
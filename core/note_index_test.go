@@ -0,0 +1,99 @@
+package core
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestNoteIndex_Resolve(t *testing.T) {
+	baseDir := filepath.FromSlash("/vault")
+	notes := map[string][]string{
+		"People/Jane Baker.md":      {"Jane", "JB"},
+		"People/Sam.md":             nil,
+		"Creators/Sam.md":           nil,
+		"Old/People/Sam.md":         nil,
+		"Alex.md":                   {"JB"},
+		"Recipes/Sourdough.md":      nil,
+		"Creators/Nested/Robin.md":  {"Rob", "rob"},
+		"Archive/Creators/Robin.md": nil,
+		"Robin.md":                  nil,
+	}
+
+	newIndex := func(dir string) *noteIndex {
+		idx := newNoteIndex(baseDir, dir)
+		for name, aliases := range notes {
+			idx.add(filepath.Join(baseDir, filepath.FromSlash(name)), aliases, true)
+		}
+		return idx
+	}
+
+	tests := []struct {
+		name      string
+		dir       string
+		link      string
+		want      string
+		ambiguous bool
+		notFound  bool
+	}{
+		{name: "unique name in folder", link: "Sourdough", want: "Recipes/Sourdough.md"},
+		{name: "case insensitive", link: "jane baker", want: "People/Jane Baker.md"},
+		{name: "extension", link: "Alex.md", want: "Alex.md"},
+		{name: "alias", link: "Jane", want: "People/Jane Baker.md"},
+		{name: "duplicate alias in one note", link: "rob", want: "Creators/Nested/Robin.md"},
+		{name: "ambiguous name", link: "Sam", ambiguous: true},
+		{name: "ambiguous alias", link: "JB", ambiguous: true},
+		{name: "full path", link: "People/Sam", want: "People/Sam.md"},
+		{name: "root note over subfolders", link: "Robin", want: "Robin.md"},
+		{name: "partial path", link: "Nested/Robin", want: "Creators/Nested/Robin.md"},
+		{name: "creators dir", dir: "Creators", link: "Sam", want: "Creators/Sam.md"},
+		{name: "creators dir nested", dir: "Creators", link: "Robin", want: "Creators/Nested/Robin.md"},
+		{name: "creators dir excludes others", dir: "Creators", link: "Jane", notFound: true},
+		{name: "missing", link: "Nobody", notFound: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newIndex(tt.dir).resolve(tt.link)
+
+			var ambiguous *AmbiguousLinkError
+			switch {
+			case tt.ambiguous:
+				if !errors.As(err, &ambiguous) || len(ambiguous.Candidates) < 2 {
+					t.Fatalf("Expected ambiguity error, got %q, %v", got, err)
+				}
+			case tt.notFound:
+				if !errors.Is(err, ErrNoteNotFound) {
+					t.Fatalf("Expected ErrNoteNotFound, got %q, %v", got, err)
+				}
+			default:
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if want := filepath.Join(baseDir, filepath.FromSlash(tt.want)); got != want {
+					t.Errorf("Expected %s, got %s", want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestNoteIndex_Link(t *testing.T) {
+	baseDir := filepath.FromSlash("/vault")
+	idx := newNoteIndex(baseDir, "People")
+	for _, name := range []string{"People/Jane Baker.md", "People/Sam.md", "Old/Sam.md", "Old/People/Sam.md", "Sam.md"} {
+		idx.add(filepath.Join(baseDir, filepath.FromSlash(name)), nil, true)
+	}
+
+	tests := map[string]string{
+		"People/Jane Baker.md": "Jane Baker",
+		"People/Sam.md":        "People/Sam",
+		"Old/People/Sam.md":    "Old/People/Sam",
+		"Sam.md":               "Sam",
+	}
+	for name, want := range tests {
+		if got := idx.link(filepath.Join(baseDir, filepath.FromSlash(name))); got != want {
+			t.Errorf("link(%s) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+
+	"github.com/go-logr/logr"
+)
+
+type parsedFile struct {
+	path string
+	parsedNote
+	err error
+	// creators holds the creator of each of the recipe's creator links, or
+	// nil where the creator could not be resolved or read.
+	creators []*CreatorInfo
+	// creatorErr joins a *CreatorError for every creator that is nil.
+	creatorErr error
+}
+
+// creatorResolver resolves each creator link and parses each creator note at
+// most once, no matter how many workers ask for the same creator at the same
+// time. Links that resolve to the same note share one *CreatorInfo.
+type creatorResolver struct {
+	logger logr.Logger
+	cache  *Cache
+	vault  vault
+	index  *noteIndex
+
+	mu    sync.Mutex
+	links map[string]*creatorCall
+	notes map[string]*creatorCall
+}
+
+type creatorCall struct {
+	once    sync.Once
+	creator *CreatorInfo
+	err     error
+}
+
+func newCreatorResolver(logger logr.Logger, cache *Cache, v vault, index *noteIndex) *creatorResolver {
+	return &creatorResolver{
+		logger: logger,
+		cache:  cache,
+		vault:  v,
+		index:  index,
+		links:  make(map[string]*creatorCall),
+		notes:  make(map[string]*creatorCall),
+	}
+}
+
+func (r *creatorResolver) resolve(name string) (*CreatorInfo, error) {
+	return r.do(r.links, name, func() (*CreatorInfo, error) {
+		path, err := r.index.resolve(name)
+		if err != nil {
+			return nil, err
+		}
+		r.logger.V(2).Info("Resolved creator", "creator", name, "path", path)
+
+		return r.do(r.notes, path, func() (*CreatorInfo, error) {
+			r.logger.V(2).Info("Parsing creator file", "path", path)
+			creator, err := r.cache.parseCreatorPath(r.logger, r.vault, path, noteName(path))
+			if err != nil {
+				return nil, err
+			}
+			creator.Link = r.index.link(path)
+			return creator, nil
+		})
+	})
+}
+
+func (r *creatorResolver) do(
+	calls map[string]*creatorCall,
+	key string,
+	fn func() (*CreatorInfo, error),
+) (*CreatorInfo, error) {
+	r.mu.Lock()
+	call, ok := calls[key]
+	if !ok {
+		call = &creatorCall{}
+		calls[key] = call
+	}
+	r.mu.Unlock()
+
+	call.once.Do(func() {
+		call.creator, call.err = fn()
+	})
+	return call.creator, call.err
+}
+
+func defaultJobs(jobs int) int {
+	if jobs <= 0 {
+		return runtime.GOMAXPROCS(0)
+	}
+	return jobs
+}
+
+// parseFiles parses files with a bounded pool of workers. Every note is
+// parsed before any creator is resolved, so that creators can be found
+// anywhere in the vault. Results are returned in the order of files
+// regardless of scheduling.
+func parseFiles(
+	ctx context.Context,
+	logger logr.Logger,
+	cache *Cache,
+	opts GenerateOptions,
+	files []string,
+) ([]parsedFile, error) {
+	results := make([]parsedFile, len(files))
+
+	jobs := min(defaultJobs(opts.Jobs), max(len(files), 1))
+	logger.V(1).Info("Parsing files", "count", len(files), "jobs", jobs)
+
+	v := opts.vault()
+	detector := noteDetector{baseDir: opts.BaseDir, Detection: opts.Detection}
+	err := runJobs(ctx, jobs, len(files), func(i int) {
+		result := &results[i]
+		result.path = files[i]
+		result.parsedNote, result.err = cache.parseNote(logger, v, detector, files[i])
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	index := newNoteIndex(opts.BaseDir, opts.CreatorsDir)
+	for _, result := range results {
+		if result.err == nil {
+			index.add(result.path, result.aliases, result.creator)
+		}
+	}
+	resolver := newCreatorResolver(logger, cache, v, index)
+
+	err = runJobs(ctx, jobs, len(files), func(i int) {
+		result := &results[i]
+		if result.err != nil || result.recipe == nil {
+			return
+		}
+
+		var errs []error
+		result.creators = make([]*CreatorInfo, len(result.recipe.Creators))
+		for j, link := range result.recipe.Creators {
+			creator, err := resolver.resolve(link.Target)
+			if err != nil {
+				errs = append(errs, &CreatorError{Creator: link.Target, Err: err})
+				continue
+			}
+			result.creators[j] = creator
+		}
+		result.creatorErr = errors.Join(errs...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// runJobs calls fn for every index below n using jobs workers. Once ctx is
+// done no more calls are started and its error is returned.
+func runJobs(ctx context.Context, jobs, n int, fn func(i int)) error {
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+
+feed:
+	for i := range n {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(indexes)
+	wg.Wait()
+	return ctx.Err()
+}
@@ -0,0 +1,123 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/go-logr/logr"
+)
+
+// FileChange is a file a build would write whose content differs from what
+// is there now.
+type FileChange struct {
+	Path string
+	// Old is the current content, empty when the file is Created.
+	Old     []byte
+	New     []byte
+	Created bool
+}
+
+// PreviewWriter holds back the files written through it and reads them
+// back as if they had been written, so a build can be previewed without
+// changing anything. Files not written through it are read from the
+// underlying writer.
+type PreviewWriter struct {
+	base    Writer
+	mu      sync.Mutex
+	pending map[string][]byte
+}
+
+func NewPreviewWriter(base Writer) *PreviewWriter {
+	return &PreviewWriter{base: base, pending: make(map[string][]byte)}
+}
+
+func (w *PreviewWriter) ReadFile(path string) ([]byte, error) {
+	w.mu.Lock()
+	content, ok := w.pending[filepath.Clean(path)]
+	w.mu.Unlock()
+	if ok {
+		return bytes.Clone(content), nil
+	}
+	return w.base.ReadFile(path)
+}
+
+func (w *PreviewWriter) WriteFile(path string, content []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.pending[filepath.Clean(path)] = bytes.Clone(content)
+	return nil
+}
+
+// Changes compares the held back files with the underlying writer and
+// returns those that differ, ordered by path.
+func (w *PreviewWriter) Changes() ([]FileChange, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	paths := make([]string, 0, len(w.pending))
+	for path := range w.pending {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+
+	var changes []FileChange
+	for _, path := range paths {
+		content := w.pending[path]
+		existing, err := w.base.ReadFile(path)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			changes = append(changes, FileChange{Path: path, New: content, Created: true})
+		case err != nil:
+			return nil, fmt.Errorf("error reading %s: %w", path, err)
+		case !bytes.Equal(existing, content):
+			changes = append(changes, FileChange{Path: path, Old: existing, New: content})
+		}
+	}
+	return changes, nil
+}
+
+// PreviewTargets builds the targets like GenerateTargets but returns the
+// files that would change instead of writing them. The cache is not
+// saved, and targets written to standard output are skipped since there is
+// nothing to compare them with. Changes of the targets that could be built
+// are returned along with the errors of those that failed.
+func PreviewTargets(ctx context.Context, logger logr.Logger, opts GenerateOptions, targets []Target) ([]FileChange, error) {
+	var previewed []Target
+	for _, target := range targets {
+		if target.Output == StdoutPath {
+			logger.Info("Skipping target written to standard output", "target", target.Name)
+			continue
+		}
+		previewed = append(previewed, target)
+	}
+	if len(previewed) == 0 {
+		return nil, nil
+	}
+
+	// The preview writer must not turn on the cache of a vault that would
+	// not be cached when built.
+	opts.NoCache = !opts.cacheEnabled()
+	preview := NewPreviewWriter(opts.writer())
+	opts.Writer = preview
+	buildErr := GenerateTargets(ctx, logger, opts, previewed)
+
+	changes, err := preview.Changes()
+	if err != nil {
+		return nil, errors.Join(buildErr, err)
+	}
+
+	cacheDir := filepath.Join(opts.BaseDir, CacheDirName) + string(filepath.Separator)
+	changes = slices.DeleteFunc(changes, func(change FileChange) bool {
+		return strings.HasPrefix(change.Path, cacheDir)
+	})
+
+	logger.V(1).Info("Previewed targets", "targets", len(previewed), "changedFiles", len(changes))
+	return changes, buildErr
+}
@@ -0,0 +1,243 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand/v2"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/go-logr/logr/testr"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	lines := func(from, to int) string {
+		var b strings.Builder
+		for i := from; i <= to; i++ {
+			b.WriteString("line " + string(rune('a'+i-1)) + "\n")
+		}
+		return b.String()
+	}
+
+	tests := []struct {
+		name     string
+		old, new string
+		want     string
+	}{
+		{
+			name: "equal",
+			old:  "a\nb\n",
+			new:  "a\nb\n",
+			want: "",
+		},
+		{
+			name: "created",
+			old:  "",
+			new:  "a\nb\n",
+			want: "--- old\n+++ new\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name: "changed line",
+			old:  lines(1, 10),
+			new:  strings.Replace(lines(1, 10), "line e\n", "line E\n", 1),
+			want: "--- old\n+++ new\n@@ -2,7 +2,7 @@\n line b\n line c\n line d\n-line e\n+line E\n line f\n line g\n line h\n",
+		},
+		{
+			name: "separate hunks",
+			old:  lines(1, 20),
+			new:  "line 0\n" + strings.TrimSuffix(lines(1, 20), "line t\n"),
+			want: "--- old\n+++ new\n@@ -1,3 +1,4 @@\n+line 0\n line a\n line b\n line c\n" +
+				"@@ -17,4 +18,3 @@\n line q\n line r\n line s\n-line t\n",
+		},
+		{
+			name: "missing newline",
+			old:  "a\nb",
+			new:  "a\nb\n",
+			want: "--- old\n+++ new\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unifiedDiff("old", "new", tt.old, tt.new); got != tt.want {
+				t.Errorf("Unexpected diff, got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffLines_Shortest(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	randomLines := func() []string {
+		lines := make([]string, rng.IntN(12))
+		for i := range lines {
+			lines[i] = string(rune('a' + rng.IntN(3)))
+		}
+		return lines
+	}
+
+	for range 500 {
+		a, b := randomLines(), randomLines()
+		ops := diffLines(a, b)
+
+		var gotA, gotB []string
+		edits := 0
+		for _, op := range ops {
+			if op.kind != '+' {
+				gotA = append(gotA, op.line)
+			}
+			if op.kind != '-' {
+				gotB = append(gotB, op.line)
+			}
+			if op.kind != ' ' {
+				edits++
+			}
+		}
+		if !slices.Equal(gotA, a) || !slices.Equal(gotB, b) {
+			t.Fatalf("diffLines(%q, %q) does not turn one into the other: %v", a, b, ops)
+		}
+
+		// The longest common subsequence gives the number of edits of a
+		// shortest script.
+		lcs := make([][]int, len(a)+1)
+		for i := range lcs {
+			lcs[i] = make([]int, len(b)+1)
+		}
+		for i := len(a) - 1; i >= 0; i-- {
+			for j := len(b) - 1; j >= 0; j-- {
+				if a[i] == b[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else {
+					lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+				}
+			}
+		}
+		if want := len(a) + len(b) - 2*lcs[0][0]; edits != want {
+			t.Fatalf("diffLines(%q, %q) made %d edits, want %d", a, b, edits, want)
+		}
+	}
+}
+
+func TestDiffLines_LargeRewrite(t *testing.T) {
+	const lines = 3000
+	a, b := make([]string, lines), make([]string, lines)
+	for i := range lines {
+		a[i] = fmt.Sprintf("old line %d\n", i)
+		b[i] = fmt.Sprintf("new line %d\n", i)
+	}
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	ops := diffLines(a, b)
+	runtime.ReadMemStats(&after)
+
+	if len(ops) != 2*lines {
+		t.Fatalf("Expected %d edits, got %d", 2*lines, len(ops))
+	}
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 8<<20 {
+		t.Errorf("Expected diffing %d rewritten lines to allocate less than 8 MiB, got %d bytes", lines, allocated)
+	}
+}
+
+func TestWriteDiff(t *testing.T) {
+	changes := []FileChange{
+		{Path: filepath.Join("vault", "Index.md"), New: []byte("a\n"), Created: true},
+		{Path: filepath.Join("vault", "site", "pic.jpg"), Old: []byte("\x00old"), New: []byte("\x00new")},
+	}
+	var buf bytes.Buffer
+	if err := WriteDiff(&buf, "vault", changes); err != nil {
+		t.Fatal(err)
+	}
+	want := "--- /dev/null\n+++ b/Index.md\n@@ -0,0 +1 @@\n+a\n" +
+		"Binary files a/site/pic.jpg and b/site/pic.jpg differ\n"
+	if got := buf.String(); got != want {
+		t.Errorf("Unexpected diff, got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestPreviewTargets(t *testing.T) {
+	logger := testr.New(t)
+	w := NewMemWriter()
+	opts := GenerateOptions{BaseDir: goldenBaseDir, FS: goldenVault(), Writer: w}
+	targets := []Target{
+		{Name: "index", Output: IndexFileName},
+		{Name: "stdout", Output: StdoutPath, Format: "json"},
+	}
+	outputPath := filepath.Join(goldenBaseDir, IndexFileName)
+
+	changes, err := PreviewTargets(t.Context(), logger, opts, targets)
+	if err != nil {
+		t.Fatalf("PreviewTargets failed: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Path != outputPath || !changes[0].Created {
+		t.Fatalf("Expected the index to be created, got %+v", changes)
+	}
+	if len(w.Files) != 0 {
+		t.Errorf("Did not expect a preview to write anything, got %s", dumpFiles(w, goldenBaseDir))
+	}
+
+	if err := GenerateTargets(t.Context(), logger, opts, targets[:1]); err != nil {
+		t.Fatalf("GenerateTargets failed: %v", err)
+	}
+	if changes, err = PreviewTargets(t.Context(), logger, opts, targets); err != nil || len(changes) != 0 {
+		t.Fatalf("Expected no changes after building, got %+v, %v", changes, err)
+	}
+
+	changes, err = PreviewTargets(t.Context(), logger, opts, []Target{{Name: "index", Output: IndexFileName, Filter: "course = main"}})
+	if err != nil {
+		t.Fatalf("PreviewTargets failed: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Created || len(changes[0].Old) == 0 {
+		t.Fatalf("Expected the index to change, got %+v", changes)
+	}
+	var buf bytes.Buffer
+	if err := WriteDiff(&buf, goldenBaseDir, changes); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), "--- a/"+IndexFileName+"\n+++ b/"+IndexFileName+"\n@@ ") ||
+		!strings.Contains(buf.String(), "\n-") {
+		t.Errorf("Expected lines removed from the index, got:\n%s", buf.String())
+	}
+}
+
+func TestPreviewTargets_InMemoryVaultWithoutWriter(t *testing.T) {
+	t.Chdir(t.TempDir())
+	logger := testr.New(t)
+	recipe := []byte("---\nfiletype: recipe\npic: pie.jpg\ncreator: \"[[Sam]]\"\n---\n")
+	vault := fstest.MapFS{
+		"Pie.md": {Data: recipe},
+		"Sam.md": {Data: []byte("---\npic: sam.jpg\n---\n")},
+	}
+
+	// A cache on disk at the path naming the vault must not be used, since
+	// builds of the vault do not use it either.
+	cache := newCache("zipvault", OSWriter{})
+	cache.Entries["Pie.md"] = &CacheEntry{
+		Size:         int64(len(recipe)),
+		Digest:       calculateDigest(recipe),
+		RecipeParsed: true,
+		Detection:    Detection{}.key(),
+		Recipe:       &RecipeInfo{Name: "Pie", Title: "Pie", ImageURL: "stale.jpg", Creators: []Wikilink{{Target: "Sam"}}},
+	}
+	cache.seen["Pie.md"] = true
+	cache.dirty = true
+	if err := cache.Save(logger); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := GenerateOptions{BaseDir: "zipvault", FS: vault}
+	changes, err := PreviewTargets(t.Context(), logger, opts, []Target{{Name: "index", Output: IndexFileName}})
+	if err != nil {
+		t.Fatalf("PreviewTargets failed: %v", err)
+	}
+	if len(changes) != 1 {
+		t.Fatalf("Expected the index to change, got %+v", changes)
+	}
+	if !strings.Contains(string(changes[0].New), "pie.jpg") {
+		t.Errorf("Expected the index to show pie.jpg, got:\n%s", changes[0].New)
+	}
+}
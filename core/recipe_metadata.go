@@ -0,0 +1,374 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RecipeMetadata holds the optional, well-known frontmatter fields of a
+// recipe. Fields that are missing or invalid are left at their zero value.
+type RecipeMetadata struct {
+	// Tags are the tags of the recipe without a leading "#".
+	Tags    []string
+	Cuisine string
+	Course  string
+	// TotalTime is the total_time field, or the sum of PrepTime and
+	// CookTime when it is not set.
+	PrepTime  time.Duration
+	CookTime  time.Duration
+	TotalTime time.Duration
+	// Servings may be fractional, such as 2.5.
+	Servings float64
+	// Rating is between 0 and MaxRating.
+	Rating float64
+	// Source is the http or https URL the recipe was adapted from.
+	Source    string
+	DateAdded time.Time
+}
+
+// MaxRating is the highest rating a recipe can have.
+const MaxRating = 5
+
+// FieldError reports a frontmatter field whose value is not valid.
+type FieldError struct {
+	Field string
+	Value interface{}
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("invalid %s %v: %v", e.Field, e.Value, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	errNotText     = errors.New("expected text")
+	errNotNumber   = errors.New("expected a number")
+	errNotDuration = errors.New(`expected an ISO 8601 duration such as PT1H30M or a time such as "45 min"`)
+	errNotDate     = errors.New("expected a date such as 2024-05-01")
+	errNotURL      = errors.New("expected an http or https URL")
+)
+
+// dateLayouts are the accepted formats of date_added.
+var dateLayouts = []string{
+	"2006-01-02",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	time.RFC3339,
+}
+
+// parseRecipeMetadata extracts the well-known fields from frontmatter. Every
+// invalid field is reported as a *FieldError, in the order of the fields of
+// RecipeMetadata.
+func parseRecipeMetadata(frontmatter map[string]interface{}) (RecipeMetadata, []error) {
+	var (
+		metadata RecipeMetadata
+		errs     []error
+	)
+	field := func(key string, parse func(value interface{}) error) {
+		value, ok := frontmatter[key]
+		if !ok || value == nil {
+			return
+		}
+		if err := parse(value); err != nil {
+			errs = append(errs, &FieldError{Field: key, Value: value, Err: err})
+		}
+	}
+
+	field("tags", func(v interface{}) (err error) {
+		metadata.Tags, err = parseTags(v)
+		return err
+	})
+	field("cuisine", func(v interface{}) (err error) {
+		metadata.Cuisine, err = parseText(v)
+		return err
+	})
+	field("course", func(v interface{}) (err error) {
+		metadata.Course, err = parseText(v)
+		return err
+	})
+	field("prep_time", func(v interface{}) (err error) {
+		metadata.PrepTime, err = parseDuration(v)
+		return err
+	})
+	field("cook_time", func(v interface{}) (err error) {
+		metadata.CookTime, err = parseDuration(v)
+		return err
+	})
+	field("total_time", func(v interface{}) (err error) {
+		metadata.TotalTime, err = parseDuration(v)
+		return err
+	})
+	field("servings", func(v interface{}) (err error) {
+		metadata.Servings, err = parseServings(v)
+		return err
+	})
+	field("rating", func(v interface{}) (err error) {
+		metadata.Rating, err = parseRating(v)
+		return err
+	})
+	field("source", func(v interface{}) (err error) {
+		metadata.Source, err = parseSource(v)
+		return err
+	})
+	field("date_added", func(v interface{}) (err error) {
+		metadata.DateAdded, err = parseDate(v)
+		return err
+	})
+
+	if metadata.TotalTime == 0 {
+		metadata.TotalTime = metadata.PrepTime + metadata.CookTime
+	}
+
+	return metadata, errs
+}
+
+// parseTags accepts a list of tags or a single comma separated string.
+func parseTags(value interface{}) ([]string, error) {
+	var items []interface{}
+	switch v := value.(type) {
+	case []interface{}:
+		items = v
+	case string:
+		for _, item := range strings.Split(v, ",") {
+			items = append(items, item)
+		}
+	default:
+		return nil, errNotText
+	}
+
+	var tags []string
+	seen := make(map[string]bool)
+	for _, item := range items {
+		if item == nil {
+			continue
+		}
+		tag := strings.TrimPrefix(strings.TrimSpace(fmt.Sprint(item)), "#")
+		if tag == "" || seen[strings.ToLower(tag)] {
+			continue
+		}
+		seen[strings.ToLower(tag)] = true
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+// parseText accepts a string or a list holding a single string.
+func parseText(value interface{}) (string, error) {
+	if list, ok := value.([]interface{}); ok && len(list) == 1 {
+		value = list[0]
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", errNotText
+	}
+	return strings.TrimSpace(s), nil
+}
+
+var (
+	isoDurationPattern = regexp.MustCompile(
+		`^P(?:(\d+)D)?(?:T(?:(\d+(?:\.\d+)?)H)?(?:(\d+(?:\.\d+)?)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+	durationPartPattern = regexp.MustCompile(
+		`(\d+(?:\.\d+)?)\s*(hours?|hrs?|h|minutes?|mins?|m|seconds?|secs?|s)`)
+	durationFillerPattern = regexp.MustCompile(`^[\s,]*(and)?[\s,]*$`)
+)
+
+// parseDuration accepts ISO 8601 durations such as PT1H30M, times written
+// out such as "1 hour 30 min" or "1h30m", and plain numbers of minutes.
+func parseDuration(value interface{}) (time.Duration, error) {
+	switch v := value.(type) {
+	case int:
+		return minutes(float64(v))
+	case float64:
+		return minutes(v)
+	case string:
+		s := strings.TrimSpace(v)
+		if n, err := strconv.ParseFloat(s, 64); err == nil {
+			return minutes(n)
+		}
+		if m := isoDurationPattern.FindStringSubmatch(strings.ToUpper(s)); m != nil {
+			if m[1]+m[2]+m[3]+m[4] == "" {
+				return 0, errNotDuration
+			}
+			return sumDuration([]string{m[1], m[2], m[3], m[4]},
+				[]time.Duration{24 * time.Hour, time.Hour, time.Minute, time.Second})
+		}
+		return parseWrittenDuration(strings.ToLower(s))
+	default:
+		return 0, errNotDuration
+	}
+}
+
+func parseWrittenDuration(s string) (time.Duration, error) {
+	matches := durationPartPattern.FindAllStringSubmatchIndex(s, -1)
+	if len(matches) == 0 {
+		return 0, errNotDuration
+	}
+
+	var total time.Duration
+	last := 0
+	for _, m := range matches {
+		if !durationFillerPattern.MatchString(s[last:m[0]]) {
+			return 0, errNotDuration
+		}
+		n, _ := strconv.ParseFloat(s[m[2]:m[3]], 64)
+		unit := time.Second
+		switch s[m[4]] {
+		case 'h':
+			unit = time.Hour
+		case 'm':
+			unit = time.Minute
+		}
+		total += time.Duration(n * float64(unit))
+		last = m[1]
+	}
+	if strings.TrimSpace(s[last:]) != "" {
+		return 0, errNotDuration
+	}
+	return total, nil
+}
+
+func sumDuration(values []string, units []time.Duration) (time.Duration, error) {
+	var total time.Duration
+	for i, value := range values {
+		if value == "" {
+			continue
+		}
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 0, errNotDuration
+		}
+		total += time.Duration(n * float64(units[i]))
+	}
+	return total, nil
+}
+
+func minutes(n float64) (time.Duration, error) {
+	if n < 0 || math.IsNaN(n) || math.IsInf(n, 0) {
+		return 0, errNotDuration
+	}
+	return time.Duration(n * float64(time.Minute)), nil
+}
+
+var leadingNumberPattern = regexp.MustCompile(`^\s*(\d+(?:\.\d+)?)`)
+
+// parseServings accepts a positive number, such as 4 or 2.5, or text
+// starting with one such as "4-6" or "4 people".
+func parseServings(value interface{}) (float64, error) {
+	var n float64
+	switch v := value.(type) {
+	case int:
+		n = float64(v)
+	case float64:
+		n = v
+	case string:
+		m := leadingNumberPattern.FindStringSubmatch(v)
+		if m == nil {
+			return 0, errNotNumber
+		}
+		n, _ = strconv.ParseFloat(m[1], 64)
+	default:
+		return 0, errNotNumber
+	}
+	if n <= 0 {
+		return 0, errors.New("expected a positive number")
+	}
+	return n, nil
+}
+
+func parseRating(value interface{}) (float64, error) {
+	var rating float64
+	switch v := value.(type) {
+	case int:
+		rating = float64(v)
+	case float64:
+		rating = v
+	case string:
+		var err error
+		if rating, err = strconv.ParseFloat(strings.TrimSpace(v), 64); err != nil {
+			return 0, errNotNumber
+		}
+	default:
+		return 0, errNotNumber
+	}
+	if rating < 0 || rating > MaxRating || math.IsNaN(rating) {
+		return 0, fmt.Errorf("expected a rating from 0 to %d", MaxRating)
+	}
+	return rating, nil
+}
+
+func parseSource(value interface{}) (string, error) {
+	s, ok := value.(string)
+	if !ok {
+		return "", errNotURL
+	}
+	s = strings.TrimSpace(s)
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", errNotURL
+	}
+	return s, nil
+}
+
+func parseDate(value interface{}) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		for _, layout := range dateLayouts {
+			if t, err := time.Parse(layout, strings.TrimSpace(v)); err == nil {
+				return t, nil
+			}
+		}
+	}
+	return time.Time{}, errNotDate
+}
+
+// formatDuration renders d the way recipes usually state times, such as
+// "1 h 30 min".
+func formatDuration(d time.Duration) string {
+	if d <= 0 {
+		return ""
+	}
+	d = d.Round(time.Minute)
+	hours, mins := int(d/time.Hour), int(d%time.Hour/time.Minute)
+	switch {
+	case hours == 0:
+		return fmt.Sprintf("%d min", mins)
+	case mins == 0:
+		return fmt.Sprintf("%d h", hours)
+	default:
+		return fmt.Sprintf("%d h %d min", hours, mins)
+	}
+}
+
+// isoDuration renders d as an ISO 8601 duration such as PT1H30M.
+func isoDuration(d time.Duration) string {
+	if d <= 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("PT")
+	if h := d / time.Hour; h > 0 {
+		fmt.Fprintf(&b, "%dH", h)
+	}
+	if m := d % time.Hour / time.Minute; m > 0 {
+		fmt.Fprintf(&b, "%dM", m)
+	}
+	if s := d % time.Minute / time.Second; s > 0 {
+		fmt.Fprintf(&b, "%dS", s)
+	}
+	if b.Len() == 2 {
+		return "PT0S"
+	}
+	return b.String()
+}
@@ -0,0 +1,140 @@
+package core
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseRecipeMetadata(t *testing.T) {
+	frontmatter := normalizeFrontmatter(map[string]interface{}{
+		"tags":       []interface{}{"#dessert", "baking", "Dessert"},
+		"cuisine":    "American",
+		"course":     []interface{}{"dessert"},
+		"prep_time":  "PT20M",
+		"cook_time":  "1 hour 15 min",
+		"servings":   "8-10",
+		"rating":     4.5,
+		"source":     "https://www.example.com/pie",
+		"date_added": "2024-05-01",
+	})
+
+	got, errs := parseRecipeMetadata(frontmatter)
+	if len(errs) != 0 {
+		t.Fatalf("Unexpected errors: %v", errs)
+	}
+
+	want := RecipeMetadata{
+		Tags:      []string{"dessert", "baking"},
+		Cuisine:   "American",
+		Course:    "dessert",
+		PrepTime:  20 * time.Minute,
+		CookTime:  75 * time.Minute,
+		TotalTime: 95 * time.Minute,
+		Servings:  8,
+		Rating:    4.5,
+		Source:    "https://www.example.com/pie",
+		DateAdded: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected metadata.\nGot:  %+v\nWant: %+v", got, want)
+	}
+}
+
+func TestParseRecipeMetadata_Invalid(t *testing.T) {
+	frontmatter := map[string]interface{}{
+		"cuisine":    []interface{}{"italian", "french"},
+		"total_time": "a while",
+		"servings":   0,
+		"rating":     6,
+		"source":     "example.com/pie",
+		"date_added": "yesterday",
+	}
+
+	got, errs := parseRecipeMetadata(frontmatter)
+
+	var fields []string
+	for _, err := range errs {
+		var fieldErr *FieldError
+		if !errors.As(err, &fieldErr) {
+			t.Fatalf("Expected a *FieldError, got %T", err)
+		}
+		fields = append(fields, fieldErr.Field)
+	}
+	want := []string{"cuisine", "total_time", "servings", "rating", "source", "date_added"}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("Expected errors for %v, got %v", want, fields)
+	}
+	if !reflect.DeepEqual(got, RecipeMetadata{}) {
+		t.Errorf("Expected invalid fields to be left empty, got %+v", got)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		value   interface{}
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: 45, want: 45 * time.Minute},
+		{value: "45", want: 45 * time.Minute},
+		{value: "45 min", want: 45 * time.Minute},
+		{value: "1h30m", want: 90 * time.Minute},
+		{value: "1.5 hours", want: 90 * time.Minute},
+		{value: "2 hours and 5 minutes", want: 125 * time.Minute},
+		{value: "PT1H30M", want: 90 * time.Minute},
+		{value: "pt45m", want: 45 * time.Minute},
+		{value: "P1DT2H", want: 26 * time.Hour},
+		{value: "PT", wantErr: true},
+		{value: "1 hamburger", wantErr: true},
+		{value: "soon", wantErr: true},
+		{value: -5, wantErr: true},
+		{value: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseDuration(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseDuration(%#v) = %v, %v; want %v, error %t", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestParseServings(t *testing.T) {
+	tests := []struct {
+		value   interface{}
+		want    float64
+		wantErr bool
+	}{
+		{value: 4, want: 4},
+		{value: 2.5, want: 2.5},
+		{value: "4.0", want: 4},
+		{value: "2.5 people", want: 2.5},
+		{value: "4-6", want: 4},
+		{value: 0, wantErr: true},
+		{value: -1.5, wantErr: true},
+		{value: "a few", wantErr: true},
+		{value: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseServings(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseServings(%#v) = %v, %v; want %v, error %t", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		0:                "",
+		45 * time.Minute: "45 min",
+		2 * time.Hour:    "2 h",
+		95 * time.Minute: "1 h 35 min",
+	} {
+		if got := formatDuration(d); got != want {
+			t.Errorf("formatDuration(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNoCreator is reported for recipes that do not list a creator.
+var ErrNoCreator = errors.New("recipe has no creator")
+
+// FileError records why a file was dropped from the index.
+type FileError struct {
+	Path string
+	Err  error
+}
+
+func (e *FileError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// CreatorError reports a creator link of a recipe that could not be resolved
+// or whose note could not be read.
+type CreatorError struct {
+	Creator string
+	Err     error
+}
+
+func (e *CreatorError) Error() string {
+	return fmt.Sprintf("creator %q: %v", e.Creator, e.Err)
+}
+
+func (e *CreatorError) Unwrap() error {
+	return e.Err
+}
+
+// ScanError lists every file that was dropped while scanning a vault, in
+// the order the files were found.
+type ScanError struct {
+	Files []*FileError
+}
+
+func (e *ScanError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d recipe file(s) dropped from the index:", len(e.Files))
+	for _, f := range e.Files {
+		b.WriteString("\n  ")
+		b.WriteString(strings.ReplaceAll(f.Error(), "\n", "\n    "))
+	}
+	return b.String()
+}
+
+func (e *ScanError) Unwrap() []error {
+	errs := make([]error, len(e.Files))
+	for i, f := range e.Files {
+		errs[i] = f
+	}
+	return errs
+}
@@ -1,58 +1,88 @@
 package core
 
 import (
+	"context"
 	"fmt"
-	"sort"
 	"strings"
 
 	"github.com/go-logr/logr"
 )
 
-type SectionMarkdownGenerator struct{}
+// SectionMarkdownGenerator renders a section per recipe. Extra columns are
+// added after the creators, with their header next to the creator links and
+// their value below it. When grouped, the sections are nested under a
+// heading per group.
+type SectionMarkdownGenerator struct {
+	Layout
+}
 
 func NewSectionMarkdownGenerator() *SectionMarkdownGenerator {
 	return &SectionMarkdownGenerator{}
 }
 
-func (g *SectionMarkdownGenerator) Generate(
-	logger logr.Logger,
-	recipes []*RecipeInfo,
-	creators map[string]*CreatorInfo,
-) (string, error) {
+func (g *SectionMarkdownGenerator) Generate(_ context.Context, in GenerateInput) (string, error) {
+	logger, recipes, creators := in.Logger, in.Recipes, in.Creators
+	groups := groupRecipes(recipes, creators, g.GroupBy)
+	heading := "##"
+	if g.GroupBy != GroupByNone {
+		heading = "###"
+	}
+
 	var sections []string
+	for _, group := range groups {
+		if group.Name != "" {
+			sections = append(sections, fmt.Sprintf("## %s\n", group.Name))
+		}
+		for _, recipe := range group.Recipes {
+			if section, ok := g.section(logger, heading, recipe, creators); ok {
+				sections = append(sections, section)
+			}
+		}
+	}
+
+	return withTOC(groups, strings.Join(sections, "\n")), nil
+}
 
-	sort.Slice(recipes, func(i, j int) bool {
-		return strings.ToLower(recipes[i].Title) < strings.ToLower(recipes[j].Title)
-	})
+func (g *SectionMarkdownGenerator) section(
+	logger logr.Logger,
+	heading string,
+	recipe *RecipeInfo,
+	creators map[string]*CreatorInfo,
+) (string, bool) {
+	refs := recipeCreators(recipe, creators)
+	if len(refs) == 0 {
+		logger.V(1).Info("Creator not found", "creators", creatorNames(recipe))
+		return "", false
+	}
 
-	for _, recipe := range recipes {
-		creator, ok := creators[recipe.Creator]
-		if !ok {
-			logger.V(1).Info("Creator not found", "creator", recipe.Creator)
-			continue
-		}
+	recipeImage := formatImage(recipe.Title, recipe.ImageURL, recipe.IsRemoteImage)
 
-		recipeImage := formatImage(recipe.Title, recipe.ImageURL, recipe.IsRemoteImage)
-		creatorImage := formatImage(creator.Name, creator.ImageURL, creator.IsRemoteImage)
+	links := make([]string, len(refs))
+	images := make([]string, len(refs))
+	for i, ref := range refs {
+		links[i] = ref.tableLink()
+		images[i] = ref.image()
+	}
+	for _, column := range g.Columns {
+		links = append(links, column.Header())
+		images = append(images, column.cell(recipe))
+	}
 
-		section := fmt.Sprintf(`## %s
+	return fmt.Sprintf(`%s %s
 [[#^%s|toc]]
 
-| [[%s]] | [[%s]] |
-|-|-|
+| %s | %s |
+|-%s|
 | %s  | %s  |
 
 `,
-			recipe.Title,
-			recipe.Slug,
-			recipe.Title,
-			creator.Name,
-			recipeImage,
-			creatorImage,
-		)
-
-		sections = append(sections, section)
-	}
-
-	return strings.Join(sections, "\n"), nil
+		heading,
+		recipe.Title,
+		recipe.Slug,
+		recipeWikilink(recipe).TableString(),
+		strings.Join(links, " | "),
+		strings.Repeat("|-", len(links)),
+		recipeImage,
+		strings.Join(images, "  | "),
+	), true
 }
@@ -13,9 +13,10 @@ func TestSectionMarkdownGenerator_Generate(t *testing.T) {
 
 	recipes := []*RecipeInfo{
 		{
+			Name:          "Test Recipe",
 			Title:         "Test Recipe",
 			ImageURL:      "test.jpg",
-			Creator:       "Test Creator",
+			Creators:      []Wikilink{{Target: "Test Creator"}},
 			IsRemoteImage: true,
 			Slug:          "test-recipe",
 		},
@@ -29,7 +30,7 @@ func TestSectionMarkdownGenerator_Generate(t *testing.T) {
 		},
 	}
 
-	result, err := generator.Generate(logger, recipes, creators)
+	result, err := generator.Generate(t.Context(), GenerateInput{Logger: logger, Recipes: recipes, Creators: creators})
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
@@ -51,3 +52,69 @@ func TestSectionMarkdownGenerator_Generate(t *testing.T) {
 		t.Errorf("Expected section not found in result. Got:\n%s", result)
 	}
 }
+
+func TestSectionMarkdownGenerator_CreatorAlias(t *testing.T) {
+	logger := testr.New(t)
+	generator := NewSectionMarkdownGenerator()
+
+	recipes := []*RecipeInfo{
+		{
+			Name:     "Bread",
+			Title:    "Bread",
+			ImageURL: "bread.jpg",
+			Creators: []Wikilink{ParseWikilink("[[People/Jane Baker#Bio|Jane]]")},
+			Slug:     "bread",
+		},
+	}
+
+	creators := map[string]*CreatorInfo{
+		"People/Jane Baker": {
+			Name:     "Jane Baker",
+			Link:     "Jane Baker",
+			ImageURL: "jane.jpg",
+		},
+	}
+
+	result, err := generator.Generate(t.Context(), GenerateInput{Logger: logger, Recipes: recipes, Creators: creators})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expectedRow := `| [[Bread]] | [[Jane Baker#Bio\|Jane]] |`
+	if !strings.Contains(result, expectedRow) {
+		t.Errorf("Expected row %s not found in result. Got:\n%s", expectedRow, result)
+	}
+}
+
+func TestSectionMarkdownGenerator_MultipleCreators(t *testing.T) {
+	logger := testr.New(t)
+	generator := NewSectionMarkdownGenerator()
+
+	recipes := []*RecipeInfo{
+		{
+			Name:     "Bread",
+			Title:    "Bread",
+			ImageURL: "bread.jpg",
+			Creators: []Wikilink{{Target: "Sam"}, {Target: "Ghost"}, {Target: "Jane"}},
+			Slug:     "bread",
+		},
+	}
+
+	creators := map[string]*CreatorInfo{
+		"Jane": {Name: "Jane", ImageURL: "jane.jpg"},
+		"Sam":  {Name: "Sam", ImageURL: "sam.jpg"},
+	}
+
+	result, err := generator.Generate(t.Context(), GenerateInput{Logger: logger, Recipes: recipes, Creators: creators})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expectedTable := `| [[Bread]] | [[Sam]] | [[Jane]] |
+|-|-|-|
+| ![[bread.jpg]]  | ![[sam.jpg]]  | ![[jane.jpg]]  |
+`
+	if !strings.Contains(result, expectedTable) {
+		t.Errorf("Expected table not found in result. Got:\n%s", result)
+	}
+}
@@ -0,0 +1,156 @@
+package core
+
+import (
+	"cmp"
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// DefaultSort is the order used when no sort is configured.
+const DefaultSort = "title"
+
+type SortKey struct {
+	Field      string
+	Descending bool
+}
+
+// SortSpec is an ordered list of sort keys where later keys break ties
+// between recipes that compare equal on earlier ones.
+type SortSpec []SortKey
+
+// sortField compares recipes on one key. Text is compared with a collator
+// so that accented letters sort next to their plain form.
+type sortField struct {
+	compare func(col *collate.Collator, a, b *RecipeInfo) int
+	// missing reports recipes without a value for the key, which sort
+	// last in either direction. Nil means every recipe has a value.
+	missing func(recipe *RecipeInfo) bool
+}
+
+var sortFields = map[string]sortField{
+	"title": {
+		compare: func(col *collate.Collator, a, b *RecipeInfo) int {
+			return col.CompareString(a.Title, b.Title)
+		},
+	},
+	"creator": {
+		compare: func(col *collate.Collator, a, b *RecipeInfo) int {
+			return compareStrings(col, creatorNames(a), creatorNames(b))
+		},
+	},
+	"date-added": {
+		compare: func(_ *collate.Collator, a, b *RecipeInfo) int {
+			return a.DateAdded.Compare(b.DateAdded)
+		},
+		missing: func(r *RecipeInfo) bool { return r.DateAdded.IsZero() },
+	},
+	"mtime": {
+		compare: func(_ *collate.Collator, a, b *RecipeInfo) int {
+			return a.ModTime.Compare(b.ModTime)
+		},
+		missing: func(r *RecipeInfo) bool { return r.ModTime.IsZero() },
+	},
+	"rating": {
+		compare: func(_ *collate.Collator, a, b *RecipeInfo) int {
+			return compareOrdered(a.Rating, b.Rating)
+		},
+		missing: func(r *RecipeInfo) bool { return r.Rating == 0 },
+	},
+	"total-time": {
+		compare: func(_ *collate.Collator, a, b *RecipeInfo) int {
+			return compareOrdered(a.TotalTime, b.TotalTime)
+		},
+		missing: func(r *RecipeInfo) bool { return r.TotalTime == 0 },
+	},
+}
+
+// ParseSortSpec parses a comma separated list of sort keys such as
+// "rating:desc,title". Keys may be written with underscores, like the
+// frontmatter fields they sort by. An empty spec sorts by title.
+func ParseSortSpec(spec string) (SortSpec, error) {
+	if strings.TrimSpace(spec) == "" {
+		spec = DefaultSort
+	}
+
+	var keys SortSpec
+	for _, term := range strings.Split(spec, ",") {
+		field, direction, _ := strings.Cut(strings.TrimSpace(term), ":")
+		field = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(field)), "_", "-")
+
+		if _, ok := sortFields[field]; !ok {
+			return nil, fmt.Errorf("unknown sort key %q, expected one of %s",
+				field, strings.Join(sortFieldNames(), ", "))
+		}
+
+		key := SortKey{Field: field}
+		switch strings.ToLower(strings.TrimSpace(direction)) {
+		case "", "asc":
+		case "desc":
+			key.Descending = true
+		default:
+			return nil, fmt.Errorf("invalid sort direction %q for key %q, expected asc or desc",
+				direction, field)
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+func compareStrings(col *collate.Collator, a, b []string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := col.CompareString(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(a), len(b))
+}
+
+func sortFieldNames() []string {
+	names := make([]string, 0, len(sortFields))
+	for name := range sortFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// newCollator returns a collator for comparing text in sort order. A
+// collator must not be shared between goroutines.
+func newCollator() *collate.Collator {
+	return collate.New(language.Und, collate.IgnoreCase)
+}
+
+// Sort orders recipes in place. Recipes that compare equal on every key keep
+// a deterministic order by source path.
+func (s SortSpec) Sort(recipes []*RecipeInfo) {
+	col := newCollator()
+	sort.SliceStable(recipes, func(i, j int) bool {
+		a, b := recipes[i], recipes[j]
+		for _, key := range s {
+			field := sortFields[key.Field]
+			if field.missing != nil {
+				aMissing, bMissing := field.missing(a), field.missing(b)
+				if aMissing != bMissing {
+					return bMissing
+				}
+				if aMissing {
+					continue
+				}
+			}
+
+			c := field.compare(col, a, b)
+			if key.Descending {
+				c = -c
+			}
+			if c != 0 {
+				return c < 0
+			}
+		}
+		return a.Path < b.Path
+	})
+}
@@ -0,0 +1,95 @@
+package core
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSortSpec_Sort(t *testing.T) {
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{spec: "", want: "apple,Banana,cherry"},
+		{spec: "title:desc", want: "cherry,Banana,apple"},
+		{spec: "creator,title:desc", want: "Banana,apple,cherry"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			spec, err := ParseSortSpec(tt.spec)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			recipes := []*RecipeInfo{
+				{Title: "cherry", Creators: []Wikilink{{Target: "Zoe"}}},
+				{Title: "Banana", Creators: []Wikilink{{Target: "adam"}}},
+				{Title: "apple", Creators: []Wikilink{{Target: "Adam"}}},
+			}
+			spec.Sort(recipes)
+
+			titles := make([]string, len(recipes))
+			for i, recipe := range recipes {
+				titles[i] = recipe.Title
+			}
+			if got := strings.Join(titles, ","); got != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseSortSpec_Errors(t *testing.T) {
+	for _, spec := range []string{"colour", "title:sideways"} {
+		if _, err := ParseSortSpec(spec); err == nil {
+			t.Errorf("Expected error for %q", spec)
+		}
+	}
+}
+
+func TestSortSpec_Sort_Metadata(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{spec: "", want: "Eclair,Éclair,Fudge,Zabaglione"},
+		{spec: "rating:desc,title", want: "Fudge,Eclair,Éclair,Zabaglione"},
+		{spec: "rating", want: "Eclair,Éclair,Fudge,Zabaglione"},
+		{spec: "date_added:desc", want: "Éclair,Eclair,Fudge,Zabaglione"},
+		{spec: "total-time,title:desc", want: "Zabaglione,Fudge,Éclair,Eclair"},
+		{spec: "mtime:desc", want: "Fudge,Zabaglione,Éclair,Eclair"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			spec, err := ParseSortSpec(tt.spec)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			recipes := []*RecipeInfo{
+				{Path: "z.md", Title: "Zabaglione", ModTime: day(3),
+					RecipeMetadata: RecipeMetadata{TotalTime: 20 * time.Minute}},
+				{Path: "f.md", Title: "Fudge", ModTime: day(4),
+					RecipeMetadata: RecipeMetadata{Rating: 5, TotalTime: 20 * time.Minute, DateAdded: day(1)}},
+				{Path: "e2.md", Title: "Éclair", ModTime: day(2),
+					RecipeMetadata: RecipeMetadata{Rating: 4, TotalTime: time.Hour, DateAdded: day(3)}},
+				{Path: "e1.md", Title: "Eclair", ModTime: day(1),
+					RecipeMetadata: RecipeMetadata{Rating: 4, TotalTime: time.Hour, DateAdded: day(2)}},
+			}
+			spec.Sort(recipes)
+
+			titles := make([]string, len(recipes))
+			for i, recipe := range recipes {
+				titles[i] = recipe.Title
+			}
+			if got := strings.Join(titles, ","); got != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
@@ -1,46 +1,78 @@
 package core
 
 import (
+	"context"
 	"fmt"
-	"sort"
 	"strings"
 
 	"github.com/go-logr/logr"
 )
 
-type TableMarkdownGenerator struct{}
+// TableMarkdownGenerator renders a table with a row per recipe. Extra
+// columns are added to the right of the creators. When grouped, there is a
+// table per group under its own heading.
+type TableMarkdownGenerator struct {
+	Layout
+}
 
 func NewTableMarkdownGenerator() *TableMarkdownGenerator {
 	return &TableMarkdownGenerator{}
 }
 
-func (g *TableMarkdownGenerator) Generate(
+func (g *TableMarkdownGenerator) Generate(_ context.Context, in GenerateInput) (string, error) {
+	logger, recipes, creators := in.Logger, in.Recipes, in.Creators
+	groups := groupRecipes(recipes, creators, g.GroupBy)
+
+	tables := make([]string, 0, len(groups))
+	for _, group := range groups {
+		table := g.table(logger, group.Recipes, creators)
+		if group.Name != "" {
+			table = fmt.Sprintf("## %s\n\n%s", group.Name, table)
+		}
+		tables = append(tables, table)
+	}
+
+	return withTOC(groups, strings.Join(tables, "\n\n")+"\n\n[Back to top](#top)\n"), nil
+}
+
+func (g *TableMarkdownGenerator) table(
 	logger logr.Logger,
 	recipes []*RecipeInfo,
 	creators map[string]*CreatorInfo,
-) (string, error) {
-	var tableRows []string
-	tableRows = append(tableRows, "| Recipe Image and Title | Creator's Image |")
-	tableRows = append(tableRows, "|------------------------|-----------------|")
+) string {
+	header := "| Recipe Image and Title | Creator's Image |"
+	separator := "|------------------------|-----------------|"
+	for _, column := range g.Columns {
+		header += " " + column.Header() + " |"
+		separator += strings.Repeat("-", len(column.Header())+2) + "|"
+	}
 
-	sort.Slice(recipes, func(i, j int) bool {
-		return strings.ToLower(recipes[i].Title) < strings.ToLower(recipes[j].Title)
-	})
+	var tableRows []string
+	tableRows = append(tableRows, header)
+	tableRows = append(tableRows, separator)
 
 	for _, recipe := range recipes {
-		creator, ok := creators[recipe.Creator]
-		if !ok {
-			logger.V(1).Info("Creator not found", "creator", recipe.Creator)
+		refs := recipeCreators(recipe, creators)
+		if len(refs) == 0 {
+			logger.V(1).Info("Creator not found", "creators", creatorNames(recipe))
 			continue
 		}
 
 		recipeImage := formatImage(recipe.Title, recipe.ImageURL, recipe.IsRemoteImage)
-		creatorImage := formatImage(creator.Name, creator.ImageURL, creator.IsRemoteImage)
 
-		tableRows = append(tableRows, fmt.Sprintf("| %s [[%s]] [[#^%s|toc]]** | %s [[%s]] |",
-			recipeImage, recipe.Title, recipe.Slug,
-			creatorImage, creator.Name))
+		cells := make([]string, len(refs))
+		for i, ref := range refs {
+			cells[i] = strings.TrimSpace(ref.image() + " " + ref.tableLink())
+		}
+
+		row := fmt.Sprintf("| %s %s [[#^%s|toc]]** | %s |",
+			recipeImage, recipeWikilink(recipe).TableString(), recipe.Slug,
+			strings.Join(cells, "<br>"))
+		for _, column := range g.Columns {
+			row += " " + column.cell(recipe) + " |"
+		}
+		tableRows = append(tableRows, row)
 	}
 
-	return strings.Join(tableRows, "\n") + "\n\n[Back to top](#top)\n", nil
+	return strings.Join(tableRows, "\n")
 }
@@ -0,0 +1,53 @@
+package core
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/testr"
+)
+
+func TestTableMarkdownGenerator_Columns(t *testing.T) {
+	logger := testr.New(t)
+	columns, err := ParseColumns("cuisine, total_time,rating,source")
+	if err != nil {
+		t.Fatal(err)
+	}
+	generator := &TableMarkdownGenerator{Layout: Layout{Columns: columns}}
+
+	recipes := []*RecipeInfo{{
+		Name:     "Bread",
+		Title:    "Bread",
+		ImageURL: "bread.jpg",
+		Creators: []Wikilink{{Target: "Sam"}},
+		Slug:     "bread",
+		RecipeMetadata: RecipeMetadata{
+			Cuisine:   "French | Rustic",
+			TotalTime: 3*time.Hour + 20*time.Minute,
+			Rating:    4,
+			Source:    "https://www.example.com/bread",
+		},
+	}}
+	creators := map[string]*CreatorInfo{"Sam": {Name: "Sam", ImageURL: "sam.jpg"}}
+
+	result, err := generator.Generate(t.Context(), GenerateInput{Logger: logger, Recipes: recipes, Creators: creators})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, want := range []string{
+		"| Recipe Image and Title | Creator's Image | Cuisine | Total Time | Rating | Source |\n" +
+			"|------------------------|-----------------|---------|------------|--------|--------|\n",
+		"| ![[bread.jpg]] [[Bread]] [[#^bread|toc]]** | ![[sam.jpg]] [[Sam]] | " +
+			"French \\| Rustic | 3 h 20 min | 4/5 | [example.com](https://www.example.com/bread) |\n",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("Expected %q in result. Got:\n%s", want, result)
+		}
+	}
+
+	if _, err := ParseColumns("cuisine,calories"); err == nil {
+		t.Error("Expected an error for an unknown column")
+	}
+}
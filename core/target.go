@@ -0,0 +1,158 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/go-logr/logr"
+)
+
+// Target is a named index built from the same vault scan as all other
+// targets, typically declared in the config file.
+type Target struct {
+	Name string `mapstructure:"name"`
+	// Output is the file to write, or the directory for the html format.
+	// Relative paths are relative to the base directory.
+	Output   string `mapstructure:"output"`
+	Format   string `mapstructure:"format"`
+	Template string `mapstructure:"template"`
+	Filter   string `mapstructure:"filter"`
+	Sort     string `mapstructure:"sort"`
+	// Columns are the extra metadata columns of the sections and table
+	// formats, see ParseColumns.
+	Columns string `mapstructure:"columns"`
+	// GroupBy puts the recipes of the sections and table formats under a
+	// heading per group, see ParseGroupBy.
+	GroupBy string `mapstructure:"group_by"`
+	// Options are settings of the format, as listed by its FormatOption
+	// schema. Columns and GroupBy are shorthands for the options of the
+	// same name.
+	Options map[string]string `mapstructure:"options"`
+}
+
+type preparedTarget struct {
+	Target
+	outputPath string
+	generator  MarkdownGenerator
+	filter     RecipeFilter
+	sortSpec   SortSpec
+}
+
+// OutputPath returns where the target is written to for a vault in baseDir.
+func (t Target) OutputPath(baseDir string) string {
+	if t.Output == StdoutPath || filepath.IsAbs(t.Output) {
+		return t.Output
+	}
+	return filepath.Join(baseDir, t.Output)
+}
+
+func (t Target) prepare(baseDir string) (*preparedTarget, error) {
+	if t.Output == "" {
+		return nil, fmt.Errorf("target %q has no output", t.Name)
+	}
+
+	prepared := &preparedTarget{Target: t, outputPath: t.OutputPath(baseDir)}
+
+	settings := make(map[string]string, len(t.Options)+2)
+	for name, value := range t.Options {
+		settings[name] = value
+	}
+	if t.Columns != "" {
+		settings["columns"] = t.Columns
+	}
+	if t.GroupBy != "" {
+		settings["group_by"] = t.GroupBy
+	}
+	if (t.Template != "" || t.Format == "html") && len(settings) > 0 {
+		return nil, fmt.Errorf("target %q: columns, group_by and options are only supported by registered formats", t.Name)
+	}
+
+	var err error
+
+	switch {
+	case t.Template != "":
+		if t.Format != "" {
+			return nil, fmt.Errorf("target %q sets both format and template", t.Name)
+		}
+		path := t.Template
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(baseDir, path)
+		}
+		prepared.generator, err = NewTemplateMarkdownGenerator(path)
+	case t.Format == "html":
+		if t.Output == StdoutPath {
+			return nil, fmt.Errorf("target %q: the html format cannot be written to stdout", t.Name)
+		}
+	default:
+		format := t.Format
+		if format == "" {
+			format = "sections"
+		}
+		prepared.generator, err = NewFormatGenerator(format, settings)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("target %q: %w", t.Name, err)
+	}
+
+	if prepared.filter, err = ParseFilter(t.Filter); err != nil {
+		return nil, fmt.Errorf("target %q: invalid filter: %w", t.Name, err)
+	}
+	if prepared.sortSpec, err = ParseSortSpec(t.Sort); err != nil {
+		return nil, fmt.Errorf("target %q: invalid sort: %w", t.Name, err)
+	}
+
+	return prepared, nil
+}
+
+// GenerateTargets scans the vault once and builds every target from the
+// result. A failing target does not stop the others from being built, but
+// no more targets are built once ctx is done.
+func GenerateTargets(ctx context.Context, logger logr.Logger, opts GenerateOptions, targets []Target) error {
+	prepared := make([]*preparedTarget, 0, len(targets))
+	for _, target := range targets {
+		p, err := target.prepare(opts.BaseDir)
+		if err != nil {
+			return err
+		}
+		prepared = append(prepared, p)
+	}
+
+	outputs := make([]string, len(prepared))
+	for i, target := range prepared {
+		outputs[i] = target.outputPath
+	}
+
+	recipes, creators, err := collectRecipes(ctx, logger, opts, outputs)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, target := range prepared {
+		if err := ctx.Err(); err != nil {
+			return errors.Join(append(errs, err)...)
+		}
+		selected := selectRecipes(recipes, target.filter, target.sortSpec)
+		logger.Info("Building target",
+			"target", target.Name, "output", target.outputPath, "recipeCount", len(selected))
+
+		if target.generator == nil {
+			err = writeHTMLSite(logger, opts, target.outputPath, selected, creators)
+		} else {
+			err = writeIndex(ctx, opts.writer(), target.generator, GenerateInput{
+				Logger:     logger,
+				Recipes:    selected,
+				Creators:   creators,
+				BaseDir:    opts.BaseDir,
+				OutputPath: target.outputPath,
+			})
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("target %q: %w", target.Name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,62 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+)
+
+func TestGenerateTargets(t *testing.T) {
+	logger := testr.New(t)
+	baseDir := writeTestVault(t, 6, 2)
+
+	targets := []Target{
+		{Name: "all", Output: "recipeindex.md", Sort: "title:desc"},
+		{Name: "creator-0", Output: "Indexes/creator-0.md", Format: "table", Filter: "creator=creator 0"},
+	}
+	opts := GenerateOptions{BaseDir: baseDir, NoCache: true}
+	if err := GenerateTargets(t.Context(), logger, opts, targets); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	all, err := os.ReadFile(filepath.Join(baseDir, "recipeindex.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first, last := strings.Index(string(all), "## Recipe 005"), strings.Index(string(all), "## Recipe 000"); first < 0 || last < first {
+		t.Errorf("Expected recipes in descending title order. Got:\n%s", all)
+	}
+
+	filtered, err := os.ReadFile(filepath.Join(baseDir, "Indexes", "creator-0.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"[[Recipe 000]]", "[[Recipe 002]]", "[[Recipe 004]]"} {
+		if !strings.Contains(string(filtered), want) {
+			t.Errorf("Expected filtered index to contain %s. Got:\n%s", want, filtered)
+		}
+	}
+	if strings.Contains(string(filtered), "[[Recipe 001]]") {
+		t.Errorf("Expected filtered index to exclude recipes by other creators. Got:\n%s", filtered)
+	}
+}
+
+func TestGenerateTargets_InvalidTarget(t *testing.T) {
+	logger := testr.New(t)
+	opts := GenerateOptions{BaseDir: t.TempDir(), NoCache: true}
+
+	for _, target := range []Target{
+		{Name: "no-output"},
+		{Name: "bad-format", Output: "x.md", Format: "pdf"},
+		{Name: "bad-filter", Output: "x.md", Filter: "tags"},
+		{Name: "bad-sort", Output: "x.md", Sort: "colour"},
+	} {
+		err := GenerateTargets(t.Context(), logger, opts, []Target{target})
+		if err == nil || !strings.Contains(err.Error(), target.Name) {
+			t.Errorf("Expected error naming target %q, got %v", target.Name, err)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/gosimple/slug"
+)
+
+// TemplateData is the value a user-supplied index template is executed with.
+type TemplateData struct {
+	// Recipes are in the configured sort order, by title unless configured
+	// otherwise.
+	Recipes  []*RecipeInfo
+	Creators map[string]*CreatorInfo
+	// TOC is the table of contents as rendered by the built-in generators,
+	// without the "# TOC" heading.
+	TOC string
+}
+
+type TemplateMarkdownGenerator struct {
+	tmpl *template.Template
+}
+
+func NewTemplateMarkdownGenerator(path string) (*TemplateMarkdownGenerator, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template: %w", err)
+	}
+
+	return NewTemplateMarkdownGeneratorFromString(filepath.Base(path), string(content))
+}
+
+func NewTemplateMarkdownGeneratorFromString(name, text string) (*TemplateMarkdownGenerator, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs(nil)).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	return &TemplateMarkdownGenerator{tmpl: tmpl}, nil
+}
+
+func (g *TemplateMarkdownGenerator) Generate(_ context.Context, in GenerateInput) (string, error) {
+	logger, recipes, creators := in.Logger, in.Recipes, in.Creators
+	data := TemplateData{
+		Recipes:  recipes,
+		Creators: creators,
+		TOC:      generateTOC(groupRecipes(recipes, creators, GroupByNone)),
+	}
+
+	tmpl, err := g.tmpl.Clone()
+	if err != nil {
+		return "", fmt.Errorf("failed to clone template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Funcs(templateFuncs(creators)).Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to execute template: %w", err)
+	}
+
+	logger.V(1).Info("Rendered template", "template", g.tmpl.Name(), "size", buf.Len())
+	return buf.String(), nil
+}
+
+func templateFuncs(creators map[string]*CreatorInfo) template.FuncMap {
+	return template.FuncMap{
+		"image":    templateImage,
+		"slug":     slug.Make,
+		"duration": formatDuration,
+		"wikilink": func(target string, alias ...string) string {
+			if len(alias) > 0 && alias[0] != "" {
+				return fmt.Sprintf("[[%s|%s]]", target, alias[0])
+			}
+			return fmt.Sprintf("[[%s]]", target)
+		},
+		"creator": func(recipe *RecipeInfo) *CreatorInfo {
+			if refs := recipeCreators(recipe, creators); len(refs) > 0 {
+				return refs[0].CreatorInfo
+			}
+			return nil
+		},
+		"creators": func(recipe *RecipeInfo) []*CreatorInfo {
+			refs := recipeCreators(recipe, creators)
+			found := make([]*CreatorInfo, len(refs))
+			for i, ref := range refs {
+				found[i] = ref.CreatorInfo
+			}
+			return found
+		},
+		"creatorlinks": func(recipe *RecipeInfo) string {
+			refs := recipeCreators(recipe, creators)
+			links := make([]string, len(refs))
+			for i, ref := range refs {
+				if ref.unknown() {
+					links[i] = ref.Name
+				} else {
+					links[i] = ref.Link.String()
+				}
+			}
+			return strings.Join(links, ", ")
+		},
+	}
+}
+
+// templateImage renders the image of a recipe or creator the same way the
+// built-in generators do.
+func templateImage(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case *RecipeInfo:
+		return formatImage(v.Title, v.ImageURL, v.IsRemoteImage), nil
+	case *CreatorInfo:
+		if v == nil {
+			return "", nil
+		}
+		return formatImage(v.Name, v.ImageURL, v.IsRemoteImage), nil
+	default:
+		return "", fmt.Errorf("image: unsupported value of type %T", v)
+	}
+}
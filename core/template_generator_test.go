@@ -0,0 +1,38 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+)
+
+func TestTemplateMarkdownGenerator_Generate(t *testing.T) {
+	logger := testr.New(t)
+
+	generator, err := NewTemplateMarkdownGeneratorFromString("index", `{{range .Recipes -}}
+- {{wikilink .Title}} by {{wikilink (creator .).Name "chef"}} {{image .}} {{image (creator .)}} #{{slug .Title}}
+{{end}}`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	recipes := []*RecipeInfo{
+		{Title: "apple Pie", ImageURL: "https://example.com/pie.jpg", Creators: []Wikilink{{Target: "Jane"}}, IsRemoteImage: true},
+		{Title: "Zucchini Bread", ImageURL: "zb.jpg", Creators: []Wikilink{{Target: "Jane"}}},
+	}
+	creators := map[string]*CreatorInfo{
+		"Jane": {Name: "Jane", ImageURL: "jane.jpg"},
+	}
+
+	result, err := generator.Generate(t.Context(), GenerateInput{Logger: logger, Recipes: recipes, Creators: creators})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := `- [[apple Pie]] by [[Jane|chef]] ![apple Pie](https://example.com/pie.jpg) ![[jane.jpg]] #apple-pie
+- [[Zucchini Bread]] by [[Jane|chef]] ![[zb.jpg]] ![[jane.jpg]] #zucchini-bread
+`
+	if result != expected {
+		t.Errorf("Unexpected result.\nGot:\n%s\nWant:\n%s", result, expected)
+	}
+}
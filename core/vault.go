@@ -0,0 +1,78 @@
+package core
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/go-logr/logr"
+)
+
+// vault reads the notes below baseDir through fsys, which is the directory
+// itself unless another file system such as a zip archive or an in-memory
+// vault is given. Paths handed out by a vault are baseDir joined with the
+// slash separated names of fsys, so that logs, errors and exports show the
+// same paths whatever the vault is read from.
+type vault struct {
+	fsys    fs.FS
+	baseDir string
+}
+
+func newVault(baseDir string, fsys fs.FS) vault {
+	if fsys == nil {
+		dir := baseDir
+		if dir == "" {
+			dir = "."
+		}
+		fsys = os.DirFS(dir)
+	}
+	return vault{fsys: fsys, baseDir: baseDir}
+}
+
+// path returns the path of the file called name in fsys.
+func (v vault) path(name string) string {
+	return filepath.Join(v.baseDir, filepath.FromSlash(name))
+}
+
+// name returns the name in fsys of the file at path.
+func (v vault) name(path string) (string, error) {
+	rel, err := filepath.Rel(filepath.Clean(v.baseDir), filepath.Clean(path))
+	if err != nil {
+		return "", err
+	}
+	name := filepath.ToSlash(rel)
+	if !fs.ValidPath(name) {
+		return "", fmt.Errorf("%s is outside of the vault %s", path, v.baseDir)
+	}
+	return name, nil
+}
+
+func (v vault) readFile(logger logr.Logger, path string) ([]byte, error) {
+	name, err := v.name(path)
+	if err == nil {
+		var content []byte
+		if content, err = fs.ReadFile(v.fsys, name); err == nil {
+			logger.V(2).Info("File read successfully", "path", path, "size", len(content))
+			return content, nil
+		}
+	}
+	logger.Error(err, "Failed to read file", "path", path)
+	return nil, err
+}
+
+func (v vault) stat(path string) (fs.FileInfo, error) {
+	name, err := v.name(path)
+	if err != nil {
+		return nil, err
+	}
+	return fs.Stat(v.fsys, name)
+}
+
+// walk calls fn for every file and directory in the vault like
+// fs.WalkDir, passing paths rather than names.
+func (v vault) walk(fn func(path string, d fs.DirEntry, err error) error) error {
+	return fs.WalkDir(v.fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		return fn(v.path(name), d, err)
+	})
+}
@@ -0,0 +1,190 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/go-logr/logr"
+)
+
+const DefaultDebounce = 500 * time.Millisecond
+
+type Watcher struct {
+	logger   logr.Logger
+	baseDir  string
+	outputs  []string
+	build    func() error
+	debounce time.Duration
+	dirs     map[string]bool
+}
+
+// NewWatcher returns a watcher that calls build whenever markdown files under
+// baseDir change. Changes to outputs, the files and directories build writes
+// to, are ignored.
+func NewWatcher(
+	logger logr.Logger,
+	baseDir string,
+	outputs []string,
+	build func() error,
+	debounce time.Duration,
+) *Watcher {
+	if debounce <= 0 {
+		debounce = DefaultDebounce
+	}
+	cleaned := make([]string, 0, len(outputs))
+	for _, output := range outputs {
+		cleaned = append(cleaned, filepath.Clean(output))
+	}
+	return &Watcher{
+		logger:   logger,
+		baseDir:  baseDir,
+		outputs:  cleaned,
+		build:    build,
+		debounce: debounce,
+		dirs:     make(map[string]bool),
+	}
+}
+
+// Run generates the index once and then regenerates it after every burst of
+// markdown changes under the base directory until ctx is cancelled.
+func (w *Watcher) Run(ctx context.Context) error {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("error creating file watcher: %w", err)
+	}
+	defer fsw.Close()
+
+	if err := w.addDirs(fsw, w.baseDir); err != nil {
+		return fmt.Errorf("error watching %s: %w", w.baseDir, err)
+	}
+
+	w.regenerate()
+	w.logger.Info("Watching for changes", "baseDir", w.baseDir, "directories", len(w.dirs))
+
+	var timer *time.Timer
+	var timerC <-chan time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			w.logger.V(1).Info("Stopping watcher", "baseDir", w.baseDir)
+			return nil
+
+		case event, ok := <-fsw.Events:
+			if !ok {
+				return nil
+			}
+			if !w.handleEvent(fsw, event) {
+				continue
+			}
+			if timer == nil {
+				timer = time.NewTimer(w.debounce)
+			} else {
+				timer.Reset(w.debounce)
+			}
+			timerC = timer.C
+
+		case err, ok := <-fsw.Errors:
+			if !ok {
+				return nil
+			}
+			w.logger.Error(err, "File watcher error")
+
+		case <-timerC:
+			timerC = nil
+			w.regenerate()
+		}
+	}
+}
+
+// handleEvent keeps the set of watched directories current and reports
+// whether the event should trigger a regeneration.
+func (w *Watcher) handleEvent(fsw *fsnotify.Watcher, event fsnotify.Event) bool {
+	w.logger.V(2).Info("Received file event", "path", event.Name, "op", event.Op.String())
+
+	if w.isOutput(event.Name) {
+		w.logger.V(2).Info("Ignoring event for output file", "path", event.Name)
+		return false
+	}
+
+	if event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename) {
+		if w.dirs[event.Name] {
+			w.logger.V(1).Info("Watched directory removed", "path", event.Name)
+			delete(w.dirs, event.Name)
+			return true
+		}
+	}
+
+	if event.Has(fsnotify.Create) {
+		info, err := os.Stat(event.Name)
+		if err == nil && info.IsDir() {
+			if isSkippedDir(event.Name) {
+				return false
+			}
+			if err := w.addDirs(fsw, event.Name); err != nil {
+				w.logger.Error(err, "Failed to watch new directory", "path", event.Name)
+			}
+			return true
+		}
+	}
+
+	if filepath.Base(event.Name) == IgnoreFileName {
+		return true
+	}
+	if !isMarkdownFile(filepath.Base(event.Name)) {
+		return false
+	}
+
+	return event.Has(fsnotify.Create) || event.Has(fsnotify.Write) ||
+		event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename)
+}
+
+func (w *Watcher) isOutput(path string) bool {
+	path = filepath.Clean(path)
+	for _, output := range w.outputs {
+		if path == output || strings.HasPrefix(path, output+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *Watcher) addDirs(fsw *fsnotify.Watcher, root string) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			w.logger.Error(err, "Error accessing path", "path", path)
+			return nil
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if isSkippedDir(path) || w.isOutput(path) {
+			w.logger.V(2).Info("Not watching directory", "path", path)
+			return filepath.SkipDir
+		}
+		if w.dirs[path] {
+			return nil
+		}
+		if err := fsw.Add(path); err != nil {
+			return err
+		}
+		w.dirs[path] = true
+		w.logger.V(2).Info("Watching directory", "path", path)
+		return nil
+	})
+}
+
+func (w *Watcher) regenerate() {
+	w.logger.V(1).Info("Regenerating index", "baseDir", w.baseDir)
+	if err := w.build(); err != nil {
+		w.logger.Error(err, "Failed to generate markdown")
+	}
+}
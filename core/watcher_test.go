@@ -0,0 +1,131 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/testr"
+)
+
+const testDebounce = 50 * time.Millisecond
+
+// startWatcher runs a watcher on baseDir until the test ends and returns
+// a channel receiving a value for every build after the initial one.
+func startWatcher(t *testing.T, baseDir string, outputs ...string) <-chan struct{} {
+	t.Helper()
+	builds := make(chan struct{}, 100)
+	build := func() error {
+		builds <- struct{}{}
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan error, 1)
+	w := NewWatcher(testr.New(t), baseDir, outputs, build, testDebounce)
+	go func() { done <- w.Run(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Run failed: %v", err)
+		}
+	})
+
+	expectBuild(t, builds)
+	return builds
+}
+
+func expectBuild(t *testing.T, builds <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-builds:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected a build")
+	}
+}
+
+func expectNoBuild(t *testing.T, builds <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-builds:
+		t.Fatal("Did not expect a build")
+	case <-time.After(6 * testDebounce):
+	}
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatcher_Debounce(t *testing.T) {
+	baseDir := t.TempDir()
+	builds := startWatcher(t, baseDir)
+
+	for _, name := range []string{"Pie.md", "Cake.md", "Soup.md", "Pie.md", "Tart.md"} {
+		writeTestFile(t, filepath.Join(baseDir, name), "---\nfiletype: recipe\n---\n")
+	}
+	expectBuild(t, builds)
+	expectNoBuild(t, builds)
+
+	writeTestFile(t, filepath.Join(baseDir, "photo.jpg"), "jpeg")
+	expectNoBuild(t, builds)
+
+	if err := os.Remove(filepath.Join(baseDir, "Pie.md")); err != nil {
+		t.Fatal(err)
+	}
+	expectBuild(t, builds)
+}
+
+func TestWatcher_IgnoresOutputs(t *testing.T) {
+	baseDir := t.TempDir()
+	index := filepath.Join(baseDir, IndexFileName)
+	site := filepath.Join(baseDir, "site")
+	writeTestFile(t, filepath.Join(site, "notes.md"), "")
+	builds := startWatcher(t, baseDir, index, site)
+
+	writeTestFile(t, index, "# TOC\n")
+	writeTestFile(t, filepath.Join(site, "notes.md"), "changed")
+	expectNoBuild(t, builds)
+
+	writeTestFile(t, filepath.Join(baseDir, "Pie.md"), "")
+	expectBuild(t, builds)
+}
+
+func TestWatcher_NewDirectories(t *testing.T) {
+	baseDir := t.TempDir()
+	builds := startWatcher(t, baseDir)
+
+	if err := os.Mkdir(filepath.Join(baseDir, "Recipes"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	expectBuild(t, builds)
+
+	writeTestFile(t, filepath.Join(baseDir, "Recipes", "Pie.md"), "")
+	expectBuild(t, builds)
+
+	if err := os.RemoveAll(filepath.Join(baseDir, "Recipes")); err != nil {
+		t.Fatal(err)
+	}
+	expectBuild(t, builds)
+}
+
+func TestWatcher_SkippedDirectories(t *testing.T) {
+	baseDir := t.TempDir()
+	writeTestFile(t, filepath.Join(baseDir, ".trash", "Old.md"), "")
+	builds := startWatcher(t, baseDir)
+
+	if err := os.Mkdir(filepath.Join(baseDir, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(baseDir, ".git", "notes.md"), "")
+	writeTestFile(t, filepath.Join(baseDir, ".trash", "Old.md"), "changed")
+	expectNoBuild(t, builds)
+}
@@ -0,0 +1,87 @@
+package core
+
+import (
+	"path"
+	"strings"
+)
+
+// Wikilink is an Obsidian link such as [[People/Jane Baker#Bio|Jane]].
+type Wikilink struct {
+	// Target is the linked note, optionally with part of its path.
+	Target string
+	// Heading is the heading or ^block anchor without the leading "#".
+	Heading string
+	// Alias is the display text after "|".
+	Alias string
+	// Embed is set for ![[...]] embeds.
+	Embed bool
+}
+
+// ParseWikilink parses s as a wikilink. The brackets are optional, so plain
+// note names parse as a link to that note. A "\|" as written inside
+// markdown tables separates the alias like "|".
+func ParseWikilink(s string) Wikilink {
+	var link Wikilink
+
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "![[") {
+		link.Embed = true
+		s = s[1:]
+	}
+	if strings.HasPrefix(s, "[[") && strings.HasSuffix(s, "]]") {
+		s = s[2 : len(s)-2]
+	}
+	s = strings.ReplaceAll(s, `\|`, "|")
+
+	if target, alias, ok := strings.Cut(s, "|"); ok {
+		s, link.Alias = target, strings.TrimSpace(alias)
+	}
+	if target, heading, ok := strings.Cut(s, "#"); ok {
+		s, link.Heading = target, strings.TrimSpace(heading)
+	}
+	link.Target = strings.TrimSpace(s)
+
+	return link
+}
+
+// Name is the file name of the target without directories and the .md
+// extension.
+func (l Wikilink) Name() string {
+	if l.Target == "" {
+		return ""
+	}
+	return strings.TrimSuffix(path.Base(l.Target), ".md")
+}
+
+// Display returns the alias, or the name of the target when there is none.
+func (l Wikilink) Display() string {
+	if l.Alias != "" {
+		return l.Alias
+	}
+	return l.Name()
+}
+
+func (l Wikilink) String() string {
+	var b strings.Builder
+	if l.Embed {
+		b.WriteString("!")
+	}
+	b.WriteString("[[")
+	b.WriteString(l.Target)
+	if l.Heading != "" {
+		b.WriteString("#")
+		b.WriteString(l.Heading)
+	}
+	if l.Alias != "" {
+		b.WriteString("|")
+		b.WriteString(l.Alias)
+	}
+	b.WriteString("]]")
+	return b.String()
+}
+
+// TableString renders the link for use inside a markdown table cell, where
+// the alias separator has to be escaped.
+func (l Wikilink) TableString() string {
+	return strings.ReplaceAll(l.String(), "|", `\|`)
+}
@@ -0,0 +1,43 @@
+package core
+
+import "testing"
+
+func TestParseWikilink(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Wikilink
+		display string
+	}{
+		{"Jane Baker", Wikilink{Target: "Jane Baker"}, "Jane Baker"},
+		{"[[Jane Baker]]", Wikilink{Target: "Jane Baker"}, "Jane Baker"},
+		{"[[People/Jane Baker|Jane]]", Wikilink{Target: "People/Jane Baker", Alias: "Jane"}, "Jane"},
+		{"[[People/Jane Baker]]", Wikilink{Target: "People/Jane Baker"}, "Jane Baker"},
+		{"[[Jane Baker#Bio]]", Wikilink{Target: "Jane Baker", Heading: "Bio"}, "Jane Baker"},
+		{"[[Jane Baker#^intro|JB]]", Wikilink{Target: "Jane Baker", Heading: "^intro", Alias: "JB"}, "JB"},
+		{`[[Jane Baker\|JB]]`, Wikilink{Target: "Jane Baker", Alias: "JB"}, "JB"},
+		{" [[ Jane Baker.md | JB ]] ", Wikilink{Target: "Jane Baker.md", Alias: "JB"}, "JB"},
+		{"![[photo.jpg|300]]", Wikilink{Target: "photo.jpg", Alias: "300", Embed: true}, "300"},
+		{"", Wikilink{}, ""},
+	}
+
+	for _, tt := range tests {
+		got := ParseWikilink(tt.input)
+		if got != tt.want {
+			t.Errorf("ParseWikilink(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+		if display := got.Display(); display != tt.display {
+			t.Errorf("ParseWikilink(%q).Display() = %q, want %q", tt.input, display, tt.display)
+		}
+	}
+}
+
+func TestWikilink_String(t *testing.T) {
+	link := Wikilink{Target: "People/Jane Baker", Heading: "Bio", Alias: "Jane"}
+
+	if got, want := link.String(), "[[People/Jane Baker#Bio|Jane]]"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if got, want := link.TableString(), `[[People/Jane Baker#Bio\|Jane]]`; got != want {
+		t.Errorf("TableString() = %q, want %q", got, want)
+	}
+}
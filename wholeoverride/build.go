@@ -0,0 +1,142 @@
+package wholeoverride
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/gkwa/wholeoverride/core"
+)
+
+// Target returns the target that builds the index described by the Format,
+// Template, Columns, GroupBy, FormatOptions, Filter and Sort of o into
+// IndexFileName in the vault.
+func (o Options) Target() Target {
+	return Target{
+		Name:     "default",
+		Output:   IndexFileName,
+		Format:   o.Format,
+		Template: o.Template,
+		Filter:   o.Filter,
+		Sort:     o.Sort,
+		Columns:  o.Columns,
+		GroupBy:  o.GroupBy,
+		Options:  o.FormatOptions,
+	}
+}
+
+// Build scans the vault once and writes every target, or opts.Target()
+// when none is given. Each target is filtered and sorted by its own Filter
+// and Sort. A failing target does not stop the others from being built.
+func Build(ctx context.Context, opts Options, targets ...Target) error {
+	if len(targets) == 0 {
+		targets = []Target{opts.Target()}
+	}
+	return convertError(core.GenerateTargets(ctx, opts.logger(), opts.generateOptions(), targetsToCore(targets)))
+}
+
+// Preview builds the targets like Build but only returns the files whose
+// content would change, without writing anything, not even the cache.
+// Targets written to StdoutPath are skipped. Changes of the targets that
+// could be built are returned along with the errors of those that failed.
+func Preview(ctx context.Context, opts Options, targets ...Target) ([]Change, error) {
+	if len(targets) == 0 {
+		targets = []Target{opts.Target()}
+	}
+	changes, err := core.PreviewTargets(ctx, opts.logger(), opts.generateOptions(), targetsToCore(targets))
+	var converted []Change
+	for _, change := range changes {
+		converted = append(converted, Change{Path: change.Path, Old: change.Old, New: change.New, Created: change.Created})
+	}
+	return converted, convertError(err)
+}
+
+// WriteDiff writes changes to w as a unified diff, with the paths below
+// baseDir relative to it.
+func WriteDiff(w io.Writer, baseDir string, changes []Change) error {
+	converted := make([]core.FileChange, len(changes))
+	for i, change := range changes {
+		converted[i] = core.FileChange{Path: change.Path, Old: change.Old, New: change.New, Created: change.Created}
+	}
+	return core.WriteDiff(w, baseDir, converted)
+}
+
+// Watch builds the targets like Build and builds them again after every
+// burst of changes to the notes of the vault, until ctx is done. Changes
+// are waited for to settle for debounce, or DefaultDebounce when it is
+// zero. Only vaults on disk can be watched.
+func Watch(ctx context.Context, opts Options, debounce time.Duration, targets ...Target) error {
+	if opts.FS != nil {
+		return fmt.Errorf("only vaults on disk can be watched")
+	}
+	if len(targets) == 0 {
+		targets = []Target{opts.Target()}
+	}
+
+	outputs := make([]string, 0, len(targets))
+	for _, target := range targets {
+		outputs = append(outputs, target.OutputPath(opts.BaseDir))
+	}
+
+	logger := opts.logger()
+	build := func() error {
+		return core.GenerateTargets(ctx, logger, opts.generateOptions(), targetsToCore(targets))
+	}
+	return core.NewWatcher(logger, opts.BaseDir, outputs, build, debounce).Run(ctx)
+}
+
+// Lint checks the frontmatter of every recipe of the vault and the creator
+// notes they reference. Diagnostics are ordered by path and line.
+func Lint(ctx context.Context, opts Options) ([]Diagnostic, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	diagnostics, err := core.Lint(opts.logger(), core.LintOptions{
+		BaseDir:        opts.BaseDir,
+		CreatorsDir:    opts.CreatorsDir,
+		Detection:      opts.Detection.core(),
+		Include:        opts.Include,
+		Exclude:        opts.Exclude,
+		KnownFiletypes: opts.KnownFiletypes,
+		FS:             opts.FS,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var converted []Diagnostic
+	for _, d := range diagnostics {
+		converted = append(converted, Diagnostic{
+			Path:     d.Path,
+			Line:     d.Line,
+			Column:   d.Column,
+			Severity: Severity(d.Severity),
+			Code:     d.Code,
+			Message:  d.Message,
+		})
+	}
+	return converted, nil
+}
+
+// HasErrors reports whether any of diagnostics is an error.
+func HasErrors(diagnostics []Diagnostic) bool {
+	return slices.ContainsFunc(diagnostics, func(d Diagnostic) bool {
+		return d.Severity == SeverityError
+	})
+}
+
+// WriteDiagnostics writes diagnostics to w as text, json or GitHub
+// workflow commands.
+func WriteDiagnostics(w io.Writer, diagnostics []Diagnostic, format string) error {
+	converted := make([]core.Diagnostic, len(diagnostics))
+	for i, d := range diagnostics {
+		converted[i] = d.core()
+	}
+	return core.WriteDiagnostics(w, converted, format)
+}
+
+// ClearCache removes the cache of the vault in opts.BaseDir.
+func ClearCache(opts Options) error {
+	return core.ClearCache(opts.logger(), opts.BaseDir)
+}
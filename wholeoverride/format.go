@@ -0,0 +1,174 @@
+package wholeoverride
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+
+	"github.com/gkwa/wholeoverride/core"
+)
+
+// Generator renders recipes into the content of an index. A generator
+// whose output is not markdown, like the json format, also has a
+// RawOutput() bool method returning true, so that Build writes its output
+// as is instead of between the index markers.
+type Generator interface {
+	Generate(ctx context.Context, in GenerateInput) (string, error)
+}
+
+// GenerateInput is what a Generator renders.
+type GenerateInput struct {
+	Logger logr.Logger
+	// Recipes are in the configured sort order.
+	Recipes []*Recipe
+	// Creators are keyed by the target of the links to them.
+	Creators map[string]*Creator
+	// BaseDir is the vault the recipes were read from.
+	BaseDir string
+	// OutputPath is the file the content is written to, StdoutPath, or
+	// empty when it is not written to a file.
+	OutputPath string
+}
+
+// FormatOption describes a setting a Format accepts.
+type FormatOption struct {
+	Name        string
+	Description string
+}
+
+// Format is an output format that generators are made for by name.
+type Format struct {
+	Name        string
+	Description string
+	// Options are the settings New accepts. Settings that are not listed
+	// are rejected before New is called.
+	Options []FormatOption
+	// New returns a generator configured with settings, which are keyed
+	// by option name and only hold the options that were set.
+	New func(settings map[string]string) (Generator, error)
+}
+
+// RegisterFormat makes f available by its name to NewGenerator, Build and
+// the --format flag of the command line tool. Names are matched case
+// insensitively and cannot be registered twice. It is typically called
+// from an init function of the package implementing the format.
+func RegisterFormat(f Format) error {
+	if f.New == nil {
+		return fmt.Errorf("format %q has no New function", f.Name)
+	}
+	options := make([]core.FormatOption, len(f.Options))
+	for i, option := range f.Options {
+		options[i] = core.FormatOption{Name: option.Name, Description: option.Description}
+	}
+	return core.RegisterFormat(core.Format{
+		Name:        f.Name,
+		Description: f.Description,
+		Options:     options,
+		New: func(settings map[string]string) (core.MarkdownGenerator, error) {
+			generator, err := f.New(settings)
+			if err != nil {
+				return nil, err
+			}
+			return toCoreGenerator(generator), nil
+		},
+	})
+}
+
+// LookupFormat returns the format registered as name.
+func LookupFormat(name string) (Format, bool) {
+	f, ok := core.LookupFormat(name)
+	if !ok {
+		return Format{}, false
+	}
+	return formatFromCore(f), true
+}
+
+// Formats returns every registered format ordered by name.
+func Formats() []Format {
+	registered := core.Formats()
+	converted := make([]Format, len(registered))
+	for i, f := range registered {
+		converted[i] = formatFromCore(f)
+	}
+	return converted
+}
+
+func formatFromCore(f core.Format) Format {
+	options := make([]FormatOption, len(f.Options))
+	for i, option := range f.Options {
+		options[i] = FormatOption{Name: option.Name, Description: option.Description}
+	}
+	return Format{
+		Name:        f.Name,
+		Description: f.Description,
+		Options:     options,
+		New: func(settings map[string]string) (Generator, error) {
+			generator, err := f.New(settings)
+			if err != nil {
+				return nil, err
+			}
+			return fromCoreGenerator(generator), nil
+		},
+	}
+}
+
+// rawOutput reports whether generator writes something other than
+// markdown.
+func rawOutput(generator any) bool {
+	raw, ok := generator.(interface{ RawOutput() bool })
+	return ok && raw.RawOutput()
+}
+
+// apiGenerator runs a Generator of this package where core expects one.
+type apiGenerator struct {
+	generator Generator
+}
+
+func toCoreGenerator(generator Generator) core.MarkdownGenerator {
+	if g, ok := generator.(coreGenerator); ok {
+		return g.generator
+	}
+	return apiGenerator{generator: generator}
+}
+
+func (g apiGenerator) Generate(ctx context.Context, in core.GenerateInput) (string, error) {
+	return g.generator.Generate(ctx, GenerateInput{
+		Logger:     in.Logger,
+		Recipes:    recipesFromCore(in.Recipes),
+		Creators:   creatorsFromCore(in.Creators),
+		BaseDir:    in.BaseDir,
+		OutputPath: in.OutputPath,
+	})
+}
+
+func (g apiGenerator) RawOutput() bool {
+	return rawOutput(g.generator)
+}
+
+// coreGenerator runs a generator of core, such as a built-in format, as a
+// Generator of this package.
+type coreGenerator struct {
+	generator core.MarkdownGenerator
+}
+
+func fromCoreGenerator(generator core.MarkdownGenerator) Generator {
+	if g, ok := generator.(apiGenerator); ok {
+		return g.generator
+	}
+	return coreGenerator{generator: generator}
+}
+
+func (g coreGenerator) Generate(ctx context.Context, in GenerateInput) (string, error) {
+	return g.generator.Generate(ctx, core.GenerateInput{
+		Logger:     in.Logger,
+		Recipes:    recipesToCore(in.Recipes),
+		Creators:   creatorsToCore(in.Creators),
+		BaseDir:    in.BaseDir,
+		OutputPath: in.OutputPath,
+	})
+}
+
+func (g coreGenerator) RawOutput() bool {
+	return rawOutput(g.generator)
+}
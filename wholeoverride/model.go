@@ -0,0 +1,209 @@
+package wholeoverride
+
+import (
+	"time"
+
+	"github.com/gkwa/wholeoverride/core"
+)
+
+// Recipe is a parsed recipe note.
+type Recipe struct {
+	// Path is the path of the note, below BaseDir.
+	Path string
+	// Name is the file name of the note without the .md extension, which
+	// is what wikilinks to the recipe use.
+	Name string
+	// Title is the title frontmatter field, else the first level one
+	// heading of the note, else Name.
+	Title string
+	// Slug is Title made safe for anchors and file names.
+	Slug string
+	// Aliases are the other names the note is known by.
+	Aliases []string
+	// ImageURL is the pic frontmatter field, which IsRemoteImage tells
+	// apart from images in the vault.
+	ImageURL      string
+	IsRemoteImage bool
+	// Creators are the creator links as written, in the order they are
+	// listed. Their targets are the keys of the creators of a Catalog.
+	Creators []Wikilink
+
+	// The well-known optional fields of the frontmatter. TotalTime is the
+	// total_time field, or PrepTime and CookTime added up.
+	Tags      []string
+	Cuisine   string
+	Course    string
+	PrepTime  time.Duration
+	CookTime  time.Duration
+	TotalTime time.Duration
+	Servings  float64
+	// Rating is between 0 and 5.
+	Rating float64
+	// Source is the http or https URL the recipe was adapted from.
+	Source    string
+	DateAdded time.Time
+
+	// Frontmatter is the whole frontmatter of the note.
+	Frontmatter map[string]any
+	// ModTime is when the note was last modified.
+	ModTime time.Time
+}
+
+// Creator is a parsed creator note.
+type Creator struct {
+	Path string
+	Name string
+	// Link is the shortest link target that resolves to the creator note,
+	// or empty when the creator was not found in the vault.
+	Link          string
+	ImageURL      string
+	IsRemoteImage bool
+	Frontmatter   map[string]any
+}
+
+// Wikilink is a parsed [[target#heading|alias]] link.
+type Wikilink struct {
+	// Target is the linked note, optionally with part of its path.
+	Target string
+	// Heading is the heading or ^block anchor without the leading "#".
+	Heading string
+	// Alias is the display text after "|".
+	Alias string
+	// Embed is set for ![[...]] embeds.
+	Embed bool
+}
+
+// Display returns the text Obsidian shows for the link.
+func (l Wikilink) Display() string {
+	return l.core().Display()
+}
+
+func (l Wikilink) core() core.Wikilink {
+	return core.Wikilink{Target: l.Target, Heading: l.Heading, Alias: l.Alias, Embed: l.Embed}
+}
+
+func wikilinkFromCore(l core.Wikilink) Wikilink {
+	return Wikilink{Target: l.Target, Heading: l.Heading, Alias: l.Alias, Embed: l.Embed}
+}
+
+func recipeFromCore(r *core.RecipeInfo) *Recipe {
+	recipe := &Recipe{
+		Path:          r.Path,
+		Name:          r.Name,
+		Title:         r.Title,
+		Slug:          r.Slug,
+		Aliases:       r.Aliases,
+		ImageURL:      r.ImageURL,
+		IsRemoteImage: r.IsRemoteImage,
+		Tags:          r.Tags,
+		Cuisine:       r.Cuisine,
+		Course:        r.Course,
+		PrepTime:      r.PrepTime,
+		CookTime:      r.CookTime,
+		TotalTime:     r.TotalTime,
+		Servings:      r.Servings,
+		Rating:        r.Rating,
+		Source:        r.Source,
+		DateAdded:     r.DateAdded,
+		Frontmatter:   r.Frontmatter,
+		ModTime:       r.ModTime,
+	}
+	for _, link := range r.Creators {
+		recipe.Creators = append(recipe.Creators, wikilinkFromCore(link))
+	}
+	return recipe
+}
+
+func (r *Recipe) core() *core.RecipeInfo {
+	recipe := &core.RecipeInfo{
+		Path:          r.Path,
+		Name:          r.Name,
+		Title:         r.Title,
+		Slug:          r.Slug,
+		Aliases:       r.Aliases,
+		ImageURL:      r.ImageURL,
+		IsRemoteImage: r.IsRemoteImage,
+		RecipeMetadata: core.RecipeMetadata{
+			Tags:      r.Tags,
+			Cuisine:   r.Cuisine,
+			Course:    r.Course,
+			PrepTime:  r.PrepTime,
+			CookTime:  r.CookTime,
+			TotalTime: r.TotalTime,
+			Servings:  r.Servings,
+			Rating:    r.Rating,
+			Source:    r.Source,
+			DateAdded: r.DateAdded,
+		},
+		Frontmatter: r.Frontmatter,
+		ModTime:     r.ModTime,
+	}
+	for _, link := range r.Creators {
+		recipe.Creators = append(recipe.Creators, link.core())
+	}
+	return recipe
+}
+
+func creatorFromCore(c *core.CreatorInfo) *Creator {
+	return &Creator{
+		Path:          c.Path,
+		Name:          c.Name,
+		Link:          c.Link,
+		ImageURL:      c.ImageURL,
+		IsRemoteImage: c.IsRemoteImage,
+		Frontmatter:   c.Frontmatter,
+	}
+}
+
+func (c *Creator) core() *core.CreatorInfo {
+	return &core.CreatorInfo{
+		Path:          c.Path,
+		Name:          c.Name,
+		Link:          c.Link,
+		ImageURL:      c.ImageURL,
+		IsRemoteImage: c.IsRemoteImage,
+		Frontmatter:   c.Frontmatter,
+	}
+}
+
+func recipesFromCore(recipes []*core.RecipeInfo) []*Recipe {
+	converted := make([]*Recipe, len(recipes))
+	for i, recipe := range recipes {
+		converted[i] = recipeFromCore(recipe)
+	}
+	return converted
+}
+
+func recipesToCore(recipes []*Recipe) []*core.RecipeInfo {
+	converted := make([]*core.RecipeInfo, len(recipes))
+	for i, recipe := range recipes {
+		converted[i] = recipe.core()
+	}
+	return converted
+}
+
+// creatorsFromCore converts the creators keyed by link target. Keys that
+// share a creator, such as a name and an alias, keep sharing it.
+func creatorsFromCore(creators map[string]*core.CreatorInfo) map[string]*Creator {
+	converted := make(map[string]*Creator, len(creators))
+	seen := make(map[*core.CreatorInfo]*Creator, len(creators))
+	for key, creator := range creators {
+		if _, ok := seen[creator]; !ok {
+			seen[creator] = creatorFromCore(creator)
+		}
+		converted[key] = seen[creator]
+	}
+	return converted
+}
+
+func creatorsToCore(creators map[string]*Creator) map[string]*core.CreatorInfo {
+	converted := make(map[string]*core.CreatorInfo, len(creators))
+	seen := make(map[*Creator]*core.CreatorInfo, len(creators))
+	for key, creator := range creators {
+		if _, ok := seen[creator]; !ok {
+			seen[creator] = creator.core()
+		}
+		converted[key] = seen[creator]
+	}
+	return converted
+}
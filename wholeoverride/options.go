@@ -0,0 +1,222 @@
+// Package wholeoverride builds recipe indexes from an Obsidian vault. It is
+// the stable API for embedding the tool in other programs; the command line
+// tool is a thin shell over it.
+//
+// Scan reads the recipes of a vault into a Catalog, which Render turns into
+// an index written to any io.Writer:
+//
+//	opts := wholeoverride.NewOptions("vault",
+//		wholeoverride.WithFilter("tags contains vegan"),
+//		wholeoverride.WithSort("rating:desc,title"),
+//		wholeoverride.WithFormat("table"),
+//	)
+//	catalog, err := wholeoverride.Scan(ctx, opts)
+//	if err != nil {
+//		return err
+//	}
+//	generator, err := wholeoverride.NewGenerator(opts)
+//	if err != nil {
+//		return err
+//	}
+//	return wholeoverride.Render(ctx, catalog, generator, os.Stdout)
+//
+// Build writes indexes and sites to files the way the generate command does.
+package wholeoverride
+
+import (
+	"io/fs"
+
+	"github.com/go-logr/logr"
+)
+
+// Options configures a scan of the vault in BaseDir and what is built from
+// it. The zero value of every field but BaseDir is a usable default. Options
+// are usually put together with NewOptions.
+type Options struct {
+	// BaseDir is the vault directory.
+	BaseDir string
+	// FS, when set, is read instead of BaseDir, which then only names the
+	// vault in paths. It can be a zip archive, an fstest.MapFS or any
+	// other file system holding a vault.
+	FS fs.FS
+	// Writer receives the files that are written, including the cache.
+	// Nil means OSWriter. A vault read from FS is only cached when Writer
+	// is set.
+	Writer Writer
+	// Logger receives progress and problems. The zero value discards them.
+	Logger logr.Logger
+	// NoCache parses every file instead of reusing cached results.
+	NoCache bool
+	// Jobs is the number of files parsed concurrently. Zero or less means
+	// runtime.GOMAXPROCS(0).
+	Jobs int
+
+	// Filter selects the recipes, e.g. "tags contains vegan and
+	// total_time <= 30m".
+	Filter string
+	// Sort orders the recipes, e.g. "rating:desc,title". Empty means
+	// DefaultSort.
+	Sort string
+
+	// Format is the registered format NewGenerator returns, such as
+	// sections, table, json or ndjson. Empty means sections.
+	Format string
+	// FormatOptions are settings of the format, as listed by its
+	// FormatOption schema.
+	FormatOptions map[string]string
+	// Template is a text/template file rendering the index instead of a
+	// built-in format. Relative paths are relative to BaseDir.
+	Template string
+	// Columns are extra metadata columns of the sections and table
+	// formats, e.g. "cuisine,total_time,rating".
+	Columns string
+	// GroupBy groups the sections and table formats by creator, tag,
+	// cuisine, course, letter or year-added.
+	GroupBy string
+
+	// CreatorsDir, when set, limits creator resolution to notes below this
+	// directory of BaseDir.
+	CreatorsDir string
+	// Detection decides which notes are recipes and which can be creators.
+	Detection Detection
+	// Include and Exclude narrow down the scanned files with gitignore
+	// style patterns, in addition to the IgnoreFileName file.
+	Include []string
+	Exclude []string
+	// MissingCreator decides what happens to recipes none of whose
+	// creators were found. Empty means MissingCreatorDrop.
+	MissingCreator MissingCreatorPolicy
+	// PlaceholderImage is shown for the unknown creator with the
+	// MissingCreatorPlaceholder policy.
+	PlaceholderImage string
+	// Strict fails with a *ScanError when any recipe had to be dropped.
+	Strict bool
+
+	// KnownFiletypes are filetype values besides "recipe" that Lint does
+	// not report as unknown.
+	KnownFiletypes []string
+}
+
+// Option sets a field of Options.
+type Option func(*Options)
+
+// NewOptions returns the options for the vault in baseDir with opts
+// applied in order.
+func NewOptions(baseDir string, opts ...Option) Options {
+	o := Options{BaseDir: baseDir}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithFS reads the vault from fsys instead of BaseDir.
+func WithFS(fsys fs.FS) Option {
+	return func(o *Options) { o.FS = fsys }
+}
+
+// WithWriter writes files through w instead of to disk.
+func WithWriter(w Writer) Option {
+	return func(o *Options) { o.Writer = w }
+}
+
+// WithLogger logs to logger.
+func WithLogger(logger logr.Logger) Option {
+	return func(o *Options) { o.Logger = logger }
+}
+
+// WithoutCache parses every file instead of reusing cached results.
+func WithoutCache() Option {
+	return func(o *Options) { o.NoCache = true }
+}
+
+// WithJobs parses jobs files concurrently.
+func WithJobs(jobs int) Option {
+	return func(o *Options) { o.Jobs = jobs }
+}
+
+// WithFilter only keeps the recipes matching the filter expression.
+func WithFilter(filter string) Option {
+	return func(o *Options) { o.Filter = filter }
+}
+
+// WithSort orders the recipes by the sort keys in spec.
+func WithSort(spec string) Option {
+	return func(o *Options) { o.Sort = spec }
+}
+
+// WithFormat renders the index in a registered format.
+func WithFormat(format string) Option {
+	return func(o *Options) { o.Format = format }
+}
+
+// WithFormatOption sets the option called name of the format.
+func WithFormatOption(name, value string) Option {
+	return func(o *Options) {
+		if o.FormatOptions == nil {
+			o.FormatOptions = make(map[string]string)
+		}
+		o.FormatOptions[name] = value
+	}
+}
+
+// WithTemplate renders the index with a text/template file.
+func WithTemplate(path string) Option {
+	return func(o *Options) { o.Template = path }
+}
+
+// WithColumns adds metadata columns to the sections and table formats.
+func WithColumns(columns string) Option {
+	return func(o *Options) { o.Columns = columns }
+}
+
+// WithGroupBy groups the sections and table formats.
+func WithGroupBy(groupBy string) Option {
+	return func(o *Options) { o.GroupBy = groupBy }
+}
+
+// WithCreatorsDir only resolves creators to notes below dir.
+func WithCreatorsDir(dir string) Option {
+	return func(o *Options) { o.CreatorsDir = dir }
+}
+
+// WithDetection tells recipes and creator notes apart with d.
+func WithDetection(d Detection) Option {
+	return func(o *Options) { o.Detection = d }
+}
+
+// WithInclude limits the scan to the files matching patterns.
+func WithInclude(patterns ...string) Option {
+	return func(o *Options) { o.Include = patterns }
+}
+
+// WithExclude skips the files matching patterns.
+func WithExclude(patterns ...string) Option {
+	return func(o *Options) { o.Exclude = patterns }
+}
+
+// WithMissingCreator applies policy to recipes whose creators are not
+// found, showing placeholderImage with MissingCreatorPlaceholder.
+func WithMissingCreator(policy MissingCreatorPolicy, placeholderImage string) Option {
+	return func(o *Options) {
+		o.MissingCreator = policy
+		o.PlaceholderImage = placeholderImage
+	}
+}
+
+// WithStrict fails instead of dropping recipes.
+func WithStrict() Option {
+	return func(o *Options) { o.Strict = true }
+}
+
+// WithKnownFiletypes stops Lint from reporting these filetype values.
+func WithKnownFiletypes(filetypes ...string) Option {
+	return func(o *Options) { o.KnownFiletypes = filetypes }
+}
+
+func (o Options) logger() logr.Logger {
+	if o.Logger.GetSink() == nil {
+		return logr.Discard()
+	}
+	return o.Logger
+}
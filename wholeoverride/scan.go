@@ -0,0 +1,154 @@
+package wholeoverride
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/go-logr/logr"
+
+	"github.com/gkwa/wholeoverride/core"
+)
+
+// Catalog is the result of a scan: the recipes of a vault and the creators
+// they reference.
+type Catalog struct {
+	// BaseDir is the vault the catalog was scanned from.
+	BaseDir string
+	// Recipes are the recipes matching the filter in sort order.
+	Recipes []*Recipe
+	// Creators are the creators found, keyed by the target of the links
+	// to them.
+	Creators map[string]*Creator
+
+	logger logr.Logger
+}
+
+// CreatorsOf returns the creators of recipe that were found, in the order
+// the recipe lists them.
+func (c *Catalog) CreatorsOf(recipe *Recipe) []*Creator {
+	var creators []*Creator
+	for _, link := range recipe.Creators {
+		if creator, ok := c.Creators[link.Target]; ok {
+			creators = append(creators, creator)
+		}
+	}
+	return creators
+}
+
+// Scan reads the recipes of the vault described by opts. Only the cache is
+// written. Scanning stops with the error of ctx once it is done.
+func Scan(ctx context.Context, opts Options) (*Catalog, error) {
+	logger := opts.logger()
+	recipes, creators, err := core.ScanRecipes(ctx, logger, opts.generateOptions())
+	if err != nil {
+		return nil, convertError(err)
+	}
+	return &Catalog{
+		BaseDir:  opts.BaseDir,
+		Recipes:  recipesFromCore(recipes),
+		Creators: creatorsFromCore(creators),
+		logger:   logger,
+	}, nil
+}
+
+// NewGenerator returns the generator for the Format, Template, Columns,
+// GroupBy and FormatOptions of opts. The html format writes a whole site
+// and is only available through Build.
+func NewGenerator(opts Options) (Generator, error) {
+	settings := opts.formatSettings()
+
+	switch {
+	case opts.Template != "":
+		if opts.Format != "" {
+			return nil, fmt.Errorf("a format and a template cannot both be set")
+		}
+		if len(settings) > 0 {
+			return nil, fmt.Errorf("columns, grouping and format options are only supported by registered formats")
+		}
+		path := opts.Template
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(opts.BaseDir, path)
+		}
+		generator, err := core.NewTemplateMarkdownGenerator(path)
+		if err != nil {
+			return nil, err
+		}
+		return fromCoreGenerator(generator), nil
+	case opts.Format == "html":
+		return nil, fmt.Errorf("the html format writes a site, build it with Build")
+	}
+
+	format := opts.Format
+	if format == "" {
+		format = "sections"
+	}
+	generator, err := core.NewFormatGenerator(format, settings)
+	if err != nil {
+		return nil, err
+	}
+	return fromCoreGenerator(generator), nil
+}
+
+// Render writes the index generator makes of catalog to w. The content is
+// written as is, without the markers that Build keeps hand-written content
+// of an existing index around.
+func Render(ctx context.Context, catalog *Catalog, generator Generator, w io.Writer) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	logger := catalog.logger
+	if logger.GetSink() == nil {
+		logger = logr.Discard()
+	}
+
+	content, err := generator.Generate(ctx, GenerateInput{
+		Logger:   logger,
+		Recipes:  catalog.Recipes,
+		Creators: catalog.Creators,
+		BaseDir:  catalog.BaseDir,
+	})
+	if err != nil {
+		return fmt.Errorf("error generating index: %w", err)
+	}
+	if _, err := io.WriteString(w, content); err != nil {
+		return fmt.Errorf("error writing index: %w", err)
+	}
+	return nil
+}
+
+// formatSettings returns the options of the format, including Columns and
+// GroupBy.
+func (o Options) formatSettings() map[string]string {
+	settings := make(map[string]string, len(o.FormatOptions)+2)
+	for name, value := range o.FormatOptions {
+		settings[name] = value
+	}
+	if o.Columns != "" {
+		settings["columns"] = o.Columns
+	}
+	if o.GroupBy != "" {
+		settings["group_by"] = o.GroupBy
+	}
+	return settings
+}
+
+func (o Options) generateOptions() core.GenerateOptions {
+	return core.GenerateOptions{
+		BaseDir:          o.BaseDir,
+		NoCache:          o.NoCache,
+		Jobs:             o.Jobs,
+		Filter:           o.Filter,
+		Sort:             o.Sort,
+		CreatorsDir:      o.CreatorsDir,
+		Detection:        o.Detection.core(),
+		Include:          o.Include,
+		Exclude:          o.Exclude,
+		MissingCreator:   core.MissingCreatorPolicy(o.MissingCreator),
+		PlaceholderImage: o.PlaceholderImage,
+		Strict:           o.Strict,
+		FS:               o.FS,
+		Writer:           o.Writer,
+	}
+}
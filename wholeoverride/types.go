@@ -0,0 +1,301 @@
+package wholeoverride
+
+import (
+	"errors"
+
+	"github.com/gkwa/wholeoverride/core"
+)
+
+// The types of this package are its own and are converted to those of the
+// core package, which implements the API and may change between releases,
+// where they are handed over.
+
+const (
+	// IndexFileName is the index written to the vault by default.
+	IndexFileName = core.IndexFileName
+	// StdoutPath as the output of a target writes it to standard output.
+	StdoutPath = core.StdoutPath
+	// CacheDirName is the directory in the vault holding the cache.
+	CacheDirName = core.CacheDirName
+	// IgnoreFileName lists the files of the vault that are not scanned.
+	IgnoreFileName = core.IgnoreFileName
+	// DefaultSort is the order used when no sort is given.
+	DefaultSort = core.DefaultSort
+	// DefaultDebounce is how long Watch waits for changes to settle.
+	DefaultDebounce = core.DefaultDebounce
+)
+
+// Target is a named index or site built by Build.
+type Target struct {
+	Name string `mapstructure:"name"`
+	// Output is the file to write, or the directory for the html format.
+	// Relative paths are relative to the base directory.
+	Output string `mapstructure:"output"`
+	// Format is a registered format or html. Template renders the index
+	// with a text/template file instead.
+	Format   string `mapstructure:"format"`
+	Template string `mapstructure:"template"`
+	Filter   string `mapstructure:"filter"`
+	Sort     string `mapstructure:"sort"`
+	// Columns and GroupBy are shorthands for the options of the sections
+	// and table formats of the same name.
+	Columns string `mapstructure:"columns"`
+	GroupBy string `mapstructure:"group_by"`
+	// Options are settings of the format, as listed by its FormatOption
+	// schema.
+	Options map[string]string `mapstructure:"options"`
+}
+
+// OutputPath returns where the target is written to for a vault in baseDir.
+func (t Target) OutputPath(baseDir string) string {
+	return t.core().OutputPath(baseDir)
+}
+
+func (t Target) core() core.Target {
+	return core.Target{
+		Name:     t.Name,
+		Output:   t.Output,
+		Format:   t.Format,
+		Template: t.Template,
+		Filter:   t.Filter,
+		Sort:     t.Sort,
+		Columns:  t.Columns,
+		GroupBy:  t.GroupBy,
+		Options:  t.Options,
+	}
+}
+
+func targetsToCore(targets []Target) []core.Target {
+	converted := make([]core.Target, len(targets))
+	for i, target := range targets {
+		converted[i] = target.core()
+	}
+	return converted
+}
+
+// Detection holds the rules telling recipes and creator notes apart.
+type Detection struct {
+	// Recipe recognizes recipes. The zero rule means filetype: recipe.
+	Recipe DetectionRule
+	// Creator recognizes creator notes. The zero rule accepts every note.
+	Creator DetectionRule
+}
+
+func (d Detection) core() core.Detection {
+	return core.Detection{Recipe: d.Recipe.core(), Creator: d.Creator.core()}
+}
+
+// RuleMatch decides whether a DetectionRule needs any or all of its
+// conditions to hold.
+type RuleMatch string
+
+const (
+	RuleMatchAny RuleMatch = "any"
+	RuleMatchAll RuleMatch = "all"
+)
+
+// DetectionRule recognizes notes by their frontmatter, tags or path, see
+// ParseDetectionRule.
+type DetectionRule struct {
+	// Frontmatter maps frontmatter keys to the value they must have. A list
+	// matches when any of its items does and "*" matches any value that is
+	// not empty.
+	Frontmatter map[string]string `mapstructure:"frontmatter" json:"frontmatter,omitempty"`
+	// Tags are tags the note must have. A tag also matches its nested tags.
+	Tags []string `mapstructure:"tags" json:"tags,omitempty"`
+	// Paths are globs matched against the path of the note relative to the
+	// base directory, such as Recipes/**/*.md.
+	Paths []string `mapstructure:"paths" json:"paths,omitempty"`
+	// Match is RuleMatchAny when empty.
+	Match RuleMatch `mapstructure:"match" json:"match,omitempty"`
+}
+
+// Validate checks the globs and the Match of the rule.
+func (r DetectionRule) Validate() error {
+	return r.core().Validate()
+}
+
+func (r DetectionRule) core() core.DetectionRule {
+	return core.DetectionRule{
+		Frontmatter: r.Frontmatter,
+		Tags:        r.Tags,
+		Paths:       r.Paths,
+		Match:       core.RuleMatch(r.Match),
+	}
+}
+
+// ParseDetectionRule parses a rule written as comma separated frontmatter
+// key=value pairs, #tags and path globs, any of which has to match, or all
+// of them when the rule starts with "all:".
+func ParseDetectionRule(spec string) (DetectionRule, error) {
+	rule, err := core.ParseDetectionRule(spec)
+	if err != nil {
+		return DetectionRule{}, err
+	}
+	return DetectionRule{
+		Frontmatter: rule.Frontmatter,
+		Tags:        rule.Tags,
+		Paths:       rule.Paths,
+		Match:       RuleMatch(rule.Match),
+	}, nil
+}
+
+// MissingCreatorPolicy decides what happens to recipes none of whose
+// creators were found.
+type MissingCreatorPolicy string
+
+const (
+	// MissingCreatorDrop leaves the recipes out.
+	MissingCreatorDrop MissingCreatorPolicy = "drop"
+	// MissingCreatorIncludeUnknown lists them under an unknown creator.
+	MissingCreatorIncludeUnknown MissingCreatorPolicy = "include-unknown"
+	// MissingCreatorPlaceholder also shows the PlaceholderImage for the
+	// unknown creator.
+	MissingCreatorPlaceholder MissingCreatorPolicy = "placeholder"
+)
+
+// ParseMissingCreatorPolicy parses drop, include-unknown or placeholder.
+// The empty string is MissingCreatorDrop.
+func ParseMissingCreatorPolicy(s string) (MissingCreatorPolicy, error) {
+	policy, err := core.ParseMissingCreatorPolicy(s)
+	return MissingCreatorPolicy(policy), err
+}
+
+// Writer stores the files that are written. Paths are OS paths.
+type Writer interface {
+	// ReadFile returns the content of the file at path, or an error
+	// matching fs.ErrNotExist when there is none.
+	ReadFile(path string) ([]byte, error)
+	// WriteFile replaces the file at path, creating its directory.
+	WriteFile(path string, content []byte) error
+}
+
+// OSWriter writes files to disk.
+type OSWriter core.OSWriter
+
+func (w OSWriter) ReadFile(path string) ([]byte, error) {
+	return core.OSWriter(w).ReadFile(path)
+}
+
+func (w OSWriter) WriteFile(path string, content []byte) error {
+	return core.OSWriter(w).WriteFile(path, content)
+}
+
+// MemWriter keeps written files in memory, keyed by their cleaned path.
+// Files can be filled in beforehand to stand for existing output.
+type MemWriter core.MemWriter
+
+// NewMemWriter returns an empty MemWriter.
+func NewMemWriter() *MemWriter {
+	return (*MemWriter)(core.NewMemWriter())
+}
+
+func (w *MemWriter) ReadFile(path string) ([]byte, error) {
+	return (*core.MemWriter)(w).ReadFile(path)
+}
+
+func (w *MemWriter) WriteFile(path string, content []byte) error {
+	return (*core.MemWriter)(w).WriteFile(path, content)
+}
+
+// FileError records why a file was dropped from the index.
+type FileError core.FileError
+
+func (e *FileError) Error() string {
+	return (*core.FileError)(e).Error()
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// ScanError lists the recipes a strict scan had to drop, in the order the
+// files were found.
+type ScanError struct {
+	Files []*FileError
+}
+
+func (e *ScanError) Error() string {
+	files := make([]*core.FileError, len(e.Files))
+	for i, f := range e.Files {
+		files[i] = (*core.FileError)(f)
+	}
+	return (&core.ScanError{Files: files}).Error()
+}
+
+func (e *ScanError) Unwrap() []error {
+	errs := make([]error, len(e.Files))
+	for i, f := range e.Files {
+		errs[i] = f
+	}
+	return errs
+}
+
+// convertError replaces a *ScanError of core, returned on its own or
+// joined with other errors, with a *ScanError of this package.
+func convertError(err error) error {
+	switch e := err.(type) {
+	case *core.ScanError:
+		files := make([]*FileError, len(e.Files))
+		for i, f := range e.Files {
+			files[i] = (*FileError)(f)
+		}
+		return &ScanError{Files: files}
+	case interface{ Unwrap() []error }:
+		var scanErr *core.ScanError
+		if !errors.As(err, &scanErr) {
+			return err
+		}
+		errs := e.Unwrap()
+		converted := make([]error, len(errs))
+		for i, joined := range errs {
+			converted[i] = convertError(joined)
+		}
+		return errors.Join(converted...)
+	default:
+		return err
+	}
+}
+
+// Severity tells errors and warnings of Lint apart.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic is a problem reported by Lint.
+type Diagnostic struct {
+	Path     string   `json:"path"`
+	Line     int      `json:"line"`
+	Column   int      `json:"column"`
+	Severity Severity `json:"severity"`
+	// Code names the kind of problem, such as missing-pic.
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	return d.core().String()
+}
+
+func (d Diagnostic) core() core.Diagnostic {
+	return core.Diagnostic{
+		Path:     d.Path,
+		Line:     d.Line,
+		Column:   d.Column,
+		Severity: core.Severity(d.Severity),
+		Code:     d.Code,
+		Message:  d.Message,
+	}
+}
+
+// Change is a file Preview found would be written with new content.
+type Change struct {
+	Path string
+	// Old is the current content, empty when the file is Created.
+	Old     []byte
+	New     []byte
+	Created bool
+}
@@ -0,0 +1,235 @@
+package wholeoverride
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/go-logr/logr/testr"
+)
+
+func testVault() fstest.MapFS {
+	return fstest.MapFS{
+		"Recipes/Bread.md": {Data: []byte("---\nfiletype: recipe\npic: bread.jpg\ncreator: \"[[Sam]]\"\n" +
+			"tags: [vegan]\nrating: 4\n---\n")},
+		"Recipes/Soup.md": {Data: []byte("---\nfiletype: recipe\npic: soup.jpg\n" +
+			"creator:\n  - \"[[Jane]]\"\n  - \"[[Sam]]\"\ntags: [vegan]\nrating: 5\n---\n")},
+		"Recipes/Steak.md": {Data: []byte("---\nfiletype: recipe\npic: steak.jpg\ncreator: \"[[Sam]]\"\n---\n")},
+		"People/Sam.md":    {Data: []byte("---\npic: sam.jpg\n---\n")},
+		"People/Jane.md":   {Data: []byte("---\npic: jane.jpg\n---\n")},
+	}
+}
+
+func TestScanAndRender(t *testing.T) {
+	w := NewMemWriter()
+	opts := NewOptions("vault",
+		WithFS(testVault()),
+		WithWriter(w),
+		WithLogger(testr.New(t)),
+		WithFilter("tags contains vegan"),
+		WithSort("rating:desc"),
+		WithFormat("table"),
+	)
+
+	catalog, err := Scan(t.Context(), opts)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	var titles []string
+	for _, recipe := range catalog.Recipes {
+		titles = append(titles, recipe.Title)
+	}
+	if got, want := strings.Join(titles, ","), "Soup,Bread"; got != want {
+		t.Errorf("Expected recipes %s, got %s", want, got)
+	}
+
+	var names []string
+	for _, creator := range catalog.CreatorsOf(catalog.Recipes[0]) {
+		names = append(names, creator.Name)
+	}
+	if got, want := strings.Join(names, ","), "Jane,Sam"; got != want {
+		t.Errorf("Expected creators %s, got %s", want, got)
+	}
+
+	generator, err := NewGenerator(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := Render(t.Context(), catalog, generator, &buf); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	got := buf.String()
+	if !strings.Contains(got, "| ![[soup.jpg]] [[Soup]]") || strings.Contains(got, "Steak") {
+		t.Errorf("Unexpected index:\n%s", got)
+	}
+	if strings.Contains(got, "wholeoverride:begin") {
+		t.Errorf("Did not expect markers in rendered output:\n%s", got)
+	}
+
+	if _, err := w.ReadFile(filepath.Join("vault", IndexFileName)); err == nil {
+		t.Error("Did not expect Scan to write an index")
+	}
+}
+
+func TestScan_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	w := NewMemWriter()
+	opts := NewOptions("vault", WithFS(testVault()), WithWriter(w), WithJobs(1))
+	if _, err := Scan(ctx, opts); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	if len(w.Files) != 0 {
+		t.Errorf("Did not expect a cancelled scan to write anything, got %d files", len(w.Files))
+	}
+
+	var buf bytes.Buffer
+	catalog := &Catalog{}
+	generator, err := NewGenerator(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Render(ctx, catalog, generator, &buf); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled from Render, got %v", err)
+	}
+}
+
+func TestNewGenerator_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{name: "html", opts: Options{Format: "html"}},
+		{name: "unknown format", opts: Options{Format: "yaml"}},
+		{name: "format and template", opts: Options{Format: "table", Template: "index.tmpl"}},
+		{name: "columns with json", opts: Options{Format: "json", Columns: "rating"}},
+		{name: "invalid group", opts: Options{GroupBy: "colour"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewGenerator(tt.opts); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+func TestBuild(t *testing.T) {
+	w := NewMemWriter()
+	opts := NewOptions("vault", WithFS(testVault()), WithWriter(w), WithoutCache(), WithSort("rating:desc"))
+	if err := Build(t.Context(), opts); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	content, err := w.ReadFile(filepath.Join("vault", IndexFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(content)
+	if !strings.HasPrefix(got, "<!-- wholeoverride:begin -->\n") {
+		t.Errorf("Expected the index to be wrapped in markers:\n%s", got)
+	}
+	if soup, bread := strings.Index(got, "## Soup"), strings.Index(got, "## Bread"); soup < 0 || soup > bread {
+		t.Errorf("Expected Soup before Bread:\n%s", got)
+	}
+}
+
+// pathsGenerator renders where its output goes, to check what generators
+// are given.
+type pathsGenerator struct{}
+
+func (pathsGenerator) Generate(_ context.Context, in GenerateInput) (string, error) {
+	return fmt.Sprintf("%d recipes from %s to %s\n", len(in.Recipes), in.BaseDir, in.OutputPath), nil
+}
+
+var registerPaths sync.Once
+
+func TestRegisterFormat_Build(t *testing.T) {
+	registerPaths.Do(func() {
+		err := RegisterFormat(Format{
+			Name:        "paths-test",
+			Description: "where the index goes",
+			New: func(map[string]string) (Generator, error) {
+				return pathsGenerator{}, nil
+			},
+		})
+		if err != nil {
+			t.Fatalf("RegisterFormat failed: %v", err)
+		}
+	})
+	if _, ok := LookupFormat("paths-test"); !ok {
+		t.Fatal("Expected the format to be registered")
+	}
+
+	w := NewMemWriter()
+	opts := NewOptions("vault", WithFS(testVault()), WithWriter(w), WithoutCache(), WithFormat("paths-test"))
+	if err := Build(t.Context(), opts); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	outputPath := filepath.Join("vault", IndexFileName)
+	content, err := w.ReadFile(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "3 recipes from vault to " + outputPath + "\n"; !strings.Contains(string(content), want) {
+		t.Errorf("Expected %q in the index, got:\n%s", want, content)
+	}
+
+	if _, err := NewGenerator(NewOptions("vault", WithFormat("paths-test"), WithFormatOption("columns", "rating"))); err == nil {
+		t.Error("Expected an error for an option the format does not have")
+	}
+}
+
+func TestPreview(t *testing.T) {
+	outputPath := filepath.Join("vault", IndexFileName)
+	w := NewMemWriter()
+	w.Files[outputPath] = []byte("<!-- wholeoverride:begin -->\nstale\n<!-- wholeoverride:end -->\n")
+	opts := NewOptions("vault", WithFS(testVault()), WithWriter(w))
+
+	changes, err := Preview(t.Context(), opts)
+	if err != nil {
+		t.Fatalf("Preview failed: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Path != outputPath {
+		t.Fatalf("Expected the index to change, got %+v", changes)
+	}
+	if len(w.Files) != 1 || string(w.Files[outputPath]) != string(changes[0].Old) {
+		t.Errorf("Did not expect Preview to write anything")
+	}
+
+	var buf bytes.Buffer
+	if err := WriteDiff(&buf, "vault", changes); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); !strings.Contains(got, "\n-stale\n") || !strings.Contains(got, "\n+## Bread\n") {
+		t.Errorf("Unexpected diff:\n%s", got)
+	}
+}
+
+func TestStrict_ScanError(t *testing.T) {
+	vault := testVault()
+	vault["Recipes/Orphan.md"] = &fstest.MapFile{Data: []byte("---\nfiletype: recipe\npic: orphan.jpg\n---\n")}
+	opts := NewOptions("vault", WithFS(vault), WithWriter(NewMemWriter()), WithoutCache(), WithStrict())
+
+	_, scanErr := Scan(t.Context(), opts)
+	buildErr := Build(t.Context(), opts)
+	for name, err := range map[string]error{"Scan": scanErr, "Build": buildErr} {
+		var strict *ScanError
+		if !errors.As(err, &strict) {
+			t.Errorf("Expected a *ScanError from %s, got %v", name, err)
+			continue
+		}
+		if len(strict.Files) != 1 || strict.Files[0].Path != filepath.Join("vault", "Recipes", "Orphan.md") {
+			t.Errorf("Expected %s to drop Orphan.md, got %v", name, strict)
+		}
+	}
+}